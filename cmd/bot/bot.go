@@ -5,15 +5,21 @@ import (
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/audiometa"
 	"github.com/warmans/audio-search-bot/internal/bot"
 	"github.com/warmans/audio-search-bot/internal/flag"
 	"github.com/warmans/audio-search-bot/internal/importer"
+	"github.com/warmans/audio-search-bot/internal/metadata"
 	"github.com/warmans/audio-search-bot/internal/search"
 	"github.com/warmans/audio-search-bot/internal/store"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"github.com/warmans/audio-search-bot/internal/vocabulary"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 )
 
 func NewBotCommand(logger *slog.Logger) *cobra.Command {
@@ -24,8 +30,19 @@ func NewBotCommand(logger *slog.Logger) *cobra.Command {
 
 	var useFilePolling bool
 	var indexPath string
+	var searchBackend string
 	var dbCfg = &store.Config{}
 	var metadataPath string
+	var filePatternsPath string
+	var mediaExtensions []string
+	var importWorkers int64
+	var statusAddr string
+	var importSource string
+	var autoTranscribe bool
+	var transcriptionWorkers int64
+	var transcriptionBackend string
+	var transcriberCfg = &transcriber.Config{}
+	var vocabularyPath string
 
 	cmd := &cobra.Command{
 		Use:   "bot",
@@ -46,13 +63,38 @@ func NewBotCommand(logger *slog.Logger) *cobra.Command {
 			if err := conn.Migrate(); err != nil {
 				panic(err.Error())
 			}
-			if indexPath == "" {
-				return fmt.Errorf("no INDEX_PATH specified")
+
+			var searcher search.Index
+			switch searchBackend {
+			case "bluge":
+				if indexPath == "" {
+					return fmt.Errorf("no INDEX_PATH specified")
+				}
+				searcher, err = search.NewBlugeSearch(indexPath)
+				if err != nil {
+					return fmt.Errorf("failed to create searcher: %w", err)
+				}
+			case "sqlite":
+				searcher = search.NewSqliteSearch(conn.Db)
+			default:
+				return fmt.Errorf("unknown search backend: %s", searchBackend)
 			}
 
-			searcher, err := search.NewBlugeSearch(indexPath)
+			filePatterns, err := metadata.LoadFilePatterns(filePatternsPath)
 			if err != nil {
-				return fmt.Errorf("failed to create searcher: %w", err)
+				return err
+			}
+
+			source := importer.Source(importSource)
+			if source != importer.SourceSRT && source != importer.SourceMetadata {
+				return fmt.Errorf("unknown import source: %s", importSource)
+			}
+
+			var mediaTranscriber transcriber.Transcriber
+			if autoTranscribe {
+				if mediaTranscriber, err = transcriber.New(logger, transcriptionBackend, transcriberCfg, vocabulary.NewSet(vocabularyPath, filePatterns, mediaPath)); err != nil {
+					return err
+				}
 			}
 
 			importWorker := importer.NewIncrementalImporter(
@@ -62,6 +104,12 @@ func NewBotCommand(logger *slog.Logger) *cobra.Command {
 				searcher,
 				logger,
 				useFilePolling,
+				filePatterns,
+				mediaExtensions,
+				int(importWorkers),
+				source,
+				mediaTranscriber,
+				int(transcriptionWorkers),
 			)
 			go func() {
 				if err := importWorker.Start(ctx); err != nil {
@@ -69,6 +117,17 @@ func NewBotCommand(logger *slog.Logger) *cobra.Command {
 				}
 			}()
 
+			if statusAddr != "" {
+				mux := http.NewServeMux()
+				mux.Handle("/status", importer.NewStatusHandler(importWorker, logger))
+				go func() {
+					logger.Info("Starting status server...", slog.String("addr", statusAddr))
+					if err := http.ListenAndServe(statusAddr, mux); err != nil {
+						logger.Error("status server failed", slog.String("err", err.Error()))
+					}
+				}()
+			}
+
 			logger.Info("Creating discord session...")
 			if discordToken == "" {
 				return fmt.Errorf("discord token is required")
@@ -83,12 +142,15 @@ func NewBotCommand(logger *slog.Logger) *cobra.Command {
 			}
 
 			logger.Info("Starting bot...")
+			srtStore := store.NewSRTStore(conn.Db)
 			bot := bot.NewBot(
 				logger,
 				session,
 				"",
 				searcher,
-				store.NewSRTStore(conn.Db),
+				srtStore,
+				srtStore,
+				importWorker,
 				mediaPath,
 			)
 			if err != nil {
@@ -116,9 +178,21 @@ func NewBotCommand(logger *slog.Logger) *cobra.Command {
 
 	flag.BoolVarEnv(cmd.Flags(), &useFilePolling, "", "use-file-polling", true, "instead of relying on filesystem events just poll for changes")
 	flag.StringVarEnv(cmd.Flags(), &indexPath, "", "index-path", "./var/index/metadata.bluge", "path to index files")
+	flag.StringVarEnv(cmd.Flags(), &searchBackend, "", "search-backend", "bluge", "search implementation to use: bluge or sqlite")
 	flag.StringVarEnv(cmd.Flags(), &metadataPath, "", "metadata-path", "./var/metadata", "path to metadata files")
+	flag.StringSliceVarEnv(cmd.Flags(), &mediaExtensions, "", "media-extensions", audiometa.DefaultMediaExtensions, "media file types to look for next to each SRT, in order of preference")
+	flag.Int64VarEnv(cmd.Flags(), &importWorkers, "", "import-workers", int64(runtime.NumCPU()), "max number of files to probe and parse concurrently during import")
+	flag.StringVarEnv(cmd.Flags(), &filePatternsPath, "", "file-patterns-path", "", "path to a JSON file describing how episode details are extracted from file names")
+
+	flag.StringVarEnv(cmd.Flags(), &importSource, "", "import-source", string(importer.SourceSRT), "import episodes from SRTs in the media path or from JSON in the metadata path: srt or metadata")
+	flag.BoolVarEnv(cmd.Flags(), &autoTranscribe, "", "auto-transcribe", false, "transcribe media that has no SRT")
+	flag.StringVarEnv(cmd.Flags(), &transcriptionBackend, "", "transcription-backend", transcriber.BackendAssemblyAI, "backend used to auto transcribe media: assemblyai or whisper")
+	flag.Int64VarEnv(cmd.Flags(), &transcriptionWorkers, "", "transcription-workers", 1, "max number of concurrent transcriptions")
+	flag.StringVarEnv(cmd.Flags(), &vocabularyPath, "", "vocabulary-path", "", "path to a dir of <publication>.json vocabularies used to improve transcription (disabled if empty)")
+	flag.StringVarEnv(cmd.Flags(), &statusAddr, "", "status-addr", "", "address to serve the import status on e.g. :8080 (disabled if empty)")
 
 	dbCfg.RegisterFlags(cmd.Flags(), "", "dialog")
+	transcriberCfg.RegisterFlags(cmd.Flags(), "")
 	flag.Parse()
 
 	return cmd
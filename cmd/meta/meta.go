@@ -27,7 +27,8 @@ func NewDumpCommand() *cobra.Command {
 			if len(args) == 0 || args[0] == "" {
 				return fmt.Errorf("first argument must be the media file path")
 			}
-			return audiometa.DumpMeta(args[0])
+			_, err := audiometa.DumpMeta(args[0])
+			return err
 		},
 	}
 }
@@ -0,0 +1,218 @@
+package quarantine
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/flag"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"log/slog"
+	"time"
+)
+
+func NewRootCommand(logger *slog.Logger) *cobra.Command {
+
+	var dbCfg = &store.Config{}
+
+	cmd := &cobra.Command{
+		Use:   "quarantine",
+		Short: "manage files that failed to import or transcribe",
+	}
+
+	cmd.AddCommand(NewListCommand(dbCfg))
+	cmd.AddCommand(NewRetryCommand(logger, dbCfg))
+	cmd.AddCommand(NewListTranscriptionsCommand(dbCfg))
+	cmd.AddCommand(NewRetryTranscriptionCommand(logger, dbCfg))
+
+	dbCfg.RegisterFlags(cmd.PersistentFlags(), "", "dialog")
+	flag.Parse()
+
+	return cmd
+}
+
+func NewListCommand(dbCfg *store.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list quarantined files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openConn(dbCfg)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			failures, err := store.NewSRTStore(conn.Db).ListImportFailures()
+			if err != nil {
+				return err
+			}
+			for _, v := range failures {
+				fmt.Fprintf(
+					cmd.OutOrStdout(),
+					"%s\tattempts: %d\tnext attempt: %s\n\t%s\n",
+					v.SRTFile,
+					v.Attempts,
+					v.NextAttempt.Format(time.RFC3339),
+					v.Error,
+				)
+			}
+			return nil
+		},
+	}
+}
+
+func NewRetryCommand(logger *slog.Logger, dbCfg *store.Config) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "retry [srt-file...]",
+		Short: "retry the given quarantined files on the next sync",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openConn(dbCfg)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			s := store.NewSRTStore(conn.Db)
+
+			srtFiles := args
+			if all {
+				failures, err := s.ListImportFailures()
+				if err != nil {
+					return err
+				}
+				srtFiles = []string{}
+				for _, v := range failures {
+					srtFiles = append(srtFiles, v.SRTFile)
+				}
+			}
+			if len(srtFiles) == 0 {
+				return fmt.Errorf("no files given")
+			}
+			for _, srtFile := range srtFiles {
+				if err := s.RetryImportFailure(srtFile); err != nil {
+					if errors.Is(err, store.ErrNotFound) {
+						return fmt.Errorf("%s is not quarantined", srtFile)
+					}
+					return err
+				}
+				logger.Info("File will be retried on next sync", slog.String("srt_file", srtFile))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "retry all quarantined files")
+
+	return cmd
+}
+
+func NewListTranscriptionsCommand(dbCfg *store.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list-transcriptions",
+		Short: "list media that failed to transcribe or is waiting to be retried",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openConn(dbCfg)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			jobs, err := listFailedTranscriptions(store.NewSRTStore(conn.Db))
+			if err != nil {
+				return err
+			}
+			for _, v := range jobs {
+				nextAttempt := "never"
+				if !v.NextAttempt.IsZero() {
+					nextAttempt = v.NextAttempt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(
+					cmd.OutOrStdout(),
+					"%s\tstatus: %s\tattempts: %d\tnext attempt: %s\n\t%s\n",
+					v.MediaFile,
+					v.Status,
+					v.Attempts,
+					nextAttempt,
+					v.Error,
+				)
+			}
+			return nil
+		},
+	}
+}
+
+func NewRetryTranscriptionCommand(logger *slog.Logger, dbCfg *store.Config) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "retry-transcription [media-file...]",
+		Short: "retry the given failed transcriptions with their attempts reset",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openConn(dbCfg)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			s := store.NewSRTStore(conn.Db)
+
+			mediaFiles := args
+			if all {
+				jobs, err := listFailedTranscriptions(s)
+				if err != nil {
+					return err
+				}
+				mediaFiles = []string{}
+				for _, v := range jobs {
+					mediaFiles = append(mediaFiles, v.MediaFile)
+				}
+			}
+			if len(mediaFiles) == 0 {
+				return fmt.Errorf("no files given")
+			}
+			for _, mediaFile := range mediaFiles {
+				if err := s.RetryTranscriptionJob(mediaFile); err != nil {
+					if errors.Is(err, store.ErrNotFound) {
+						return fmt.Errorf("%s has not failed", mediaFile)
+					}
+					return err
+				}
+				logger.Info("Media will be transcribed again", slog.String("media_file", mediaFile))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "retry all failed transcriptions")
+
+	return cmd
+}
+
+// listFailedTranscriptions returns jobs that have failed, including those that are waiting to be retried.
+func listFailedTranscriptions(s *store.SRTStore) ([]store.TranscriptionJob, error) {
+	failed, err := s.ListTranscriptionJobs(store.TranscriptionFailed)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.ListTranscriptionJobs(store.TranscriptionPending)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range pending {
+		if v.Error != "" {
+			failed = append(failed, v)
+		}
+	}
+	return failed, nil
+}
+
+func openConn(dbCfg *store.Config) (*store.Conn, error) {
+	conn, err := store.NewConn(dbCfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Migrate(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/flag"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+func NewRootCommand(logger *slog.Logger) *cobra.Command {
+
+	var dbCfg = &store.Config{}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "reports on the imported dialog",
+	}
+
+	cmd.AddCommand(NewConfidenceCommand(dbCfg))
+
+	dbCfg.RegisterFlags(cmd.PersistentFlags(), "", "dialog")
+	flag.Parse()
+
+	return cmd
+}
+
+// NewConfidenceCommand lists the lines that were probably transcribed incorrectly so they can be proofread.
+func NewConfidenceCommand(dbCfg *store.Config) *cobra.Command {
+	var (
+		threshold   float64
+		publication string
+	)
+	cmd := &cobra.Command{
+		Use:   "confidence",
+		Short: "list dialog with a low transcription confidence by episode",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := store.NewConn(dbCfg)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			if err := conn.Migrate(); err != nil {
+				return err
+			}
+
+			dialog, err := store.NewSRTStore(conn.Db).ListLowConfidenceDialog(publication, threshold)
+			if err != nil {
+				return err
+			}
+
+			var mediaID string
+			for _, v := range dialog {
+				if v.MediaID != mediaID {
+					if mediaID != "" {
+						fmt.Fprintln(cmd.OutOrStdout())
+					}
+					mediaID = v.MediaID
+					fmt.Fprintln(cmd.OutOrStdout(), mediaID)
+				}
+				fmt.Fprintf(
+					cmd.OutOrStdout(),
+					"\t#%d\t%s\t%.2f\t%s\n",
+					v.Pos,
+					formatTimestamp(v.StartTimestamp),
+					*v.Confidence,
+					strings.ReplaceAll(v.Content, "\n", " "),
+				)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\n%d lines below %.2f\n", len(dialog), threshold)
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64Var(&threshold, "threshold", 0.6, "list dialog with a confidence below this value (0-1)")
+	cmd.Flags().StringVar(&publication, "publication", "", "only list dialog from this publication")
+
+	return cmd
+}
+
+func formatTimestamp(ts time.Duration) string {
+	return time.Unix(0, 0).UTC().Add(ts).Format("15:04:05")
+}
@@ -4,6 +4,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/warmans/audio-search-bot/cmd/bot"
 	"github.com/warmans/audio-search-bot/cmd/meta"
+	"github.com/warmans/audio-search-bot/cmd/quarantine"
+	"github.com/warmans/audio-search-bot/cmd/report"
+	"github.com/warmans/audio-search-bot/cmd/srt"
 	"github.com/warmans/audio-search-bot/cmd/transcribe"
 	"log/slog"
 )
@@ -24,6 +27,9 @@ func Execute(logger *slog.Logger) error {
 	rootCmd.AddCommand(bot.NewBotCommand(logger))
 	rootCmd.AddCommand(transcribe.NewRootCommand(logger))
 	rootCmd.AddCommand(meta.NewRootCommand(logger))
+	rootCmd.AddCommand(quarantine.NewRootCommand(logger))
+	rootCmd.AddCommand(report.NewRootCommand(logger))
+	rootCmd.AddCommand(srt.NewRootCommand(logger))
 
 	return rootCmd.Execute()
 }
@@ -0,0 +1,166 @@
+package srt
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func NewRootCommand(logger *slog.Logger) *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "srt",
+		Short: "check and clean up SRT files before they are imported",
+	}
+
+	cmd.AddCommand(NewLintCommand())
+	cmd.AddCommand(NewFixCommand(logger))
+
+	return cmd
+}
+
+func NewLintCommand() *cobra.Command {
+	opts := srt.DefaultLintOptions()
+	cmd := &cobra.Command{
+		Use:   "lint [dir|glob...]",
+		Short: "report overlapping, zero length and overlong lines, out of order positions and HTML tags",
+		Args:  cobra.MinimumNArgs(1),
+		// problems are reported as an error so that the exit code can be checked, which is not a usage error.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srtPaths, err := findSRTs(args)
+			if err != nil {
+				return err
+			}
+			total := 0
+			for _, srtPath := range srtPaths {
+				problems, err := lintFile(srtPath, opts)
+				if err != nil {
+					return err
+				}
+				for _, p := range problems {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", srtPath, p)
+				}
+				total += len(problems)
+			}
+			if total > 0 {
+				return fmt.Errorf("found %d problems in %d files", total, len(srtPaths))
+			}
+			return nil
+		},
+	}
+	opts.RegisterFlags(cmd.Flags())
+	return cmd
+}
+
+func NewFixCommand(logger *slog.Logger) *cobra.Command {
+	var (
+		dryRun bool
+		force  bool
+		opts   = srt.DefaultLintOptions()
+	)
+	cmd := &cobra.Command{
+		Use:   "fix [dir|glob...]",
+		Short: "renumber, merge tiny lines and split overlong lines. The original file is kept with a .orig extension.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srtPaths, err := findSRTs(args)
+			if err != nil {
+				return err
+			}
+			for _, srtPath := range srtPaths {
+				dialog, warnings, err := readFile(srtPath)
+				if err != nil {
+					return err
+				}
+				for _, w := range warnings {
+					logger.Warn("Malformed block", slog.String("i", srtPath), slog.Int("line", w.Line), slog.String("warning", w.Message))
+				}
+				fixed := srt.Fix(dialog, opts)
+				// skipped blocks are not in the fixed dialog so re-writing the file would lose them.
+				dropped := skippedLines(warnings)
+				if len(dropped) > 0 && !force {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: not fixed, malformed blocks at lines %s would be dropped (use --force to drop them)\n", srtPath, strings.Join(dropped, ", "))
+					continue
+				}
+				if len(dropped) > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %d lines -> %d lines, dropped malformed blocks at lines %s\n", srtPath, len(dialog), len(fixed), strings.Join(dropped, ", "))
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %d lines -> %d lines\n", srtPath, len(dialog), len(fixed))
+				}
+				if dryRun {
+					continue
+				}
+				if err := srt.Backup(srtPath); err != nil {
+					return err
+				}
+				if err := srt.WriteFile(fixed, srtPath); err != nil {
+					return fmt.Errorf("failed to write %s: %w", srtPath, err)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report the changes without re-writing any SRTs")
+	cmd.Flags().BoolVar(&force, "force", false, "re-write SRTs with malformed blocks, dropping the blocks")
+	opts.RegisterFlags(cmd.Flags())
+	return cmd
+}
+
+func skippedLines(warnings []model.ParseWarning) []string {
+	lines := []string{}
+	for _, w := range warnings {
+		if w.Skipped {
+			lines = append(lines, strconv.Itoa(w.Line))
+		}
+	}
+	return lines
+}
+
+func lintFile(srtPath string, opts srt.LintOptions) ([]srt.Problem, error) {
+	f, err := os.Open(srtPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	problems, err := srt.Lint(f, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", srtPath, err)
+	}
+	return problems, nil
+}
+
+func readFile(srtPath string) ([]model.Dialog, []model.ParseWarning, error) {
+	f, err := os.Open(srtPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	// lines are not limited since that is done by Fix.
+	dialog, warnings, err := srt.ReadLenient(f, false, time.Duration(math.MaxInt64))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", srtPath, err)
+	}
+	return dialog, warnings, nil
+}
+
+// findSRTs expands the args to a sorted list of SRTs. Each arg may be a directory which is searched recursively,
+// or a glob.
+func findSRTs(args []string) ([]string, error) {
+	return util.FindFiles(args, isSRT)
+}
+
+func isSRT(filePath string) bool {
+	return strings.ToLower(path.Ext(filePath)) == ".srt"
+}
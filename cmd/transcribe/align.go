@@ -0,0 +1,114 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"github.com/warmans/audio-search-bot/internal/subtitle"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"log/slog"
+	"math"
+	"path"
+	"strings"
+	"time"
+)
+
+// NewAlignCommand re-times existing subtitles (e.g. from subtitle sites) using a transcript of the same audio. The
+// original SRT is kept with a .orig extension so that the importer ignores it. Other formats are written as a new
+// SRT alongside the original.
+func NewAlignCommand(logger *slog.Logger) *cobra.Command {
+	var (
+		backend string
+		dryRun  bool
+		cfg     = &transcriber.Config{}
+		align   = transcriber.DefaultAlignOptions()
+	)
+	cmd := &cobra.Command{
+		Use:   "align [dir|glob...]",
+		Short: "Re-align the timings of existing SRTs to a transcript of the media and report the drift",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			var client transcriber.Transcriber
+			if backend != "" {
+				var err error
+				if client, err = transcriber.New(logger, backend, cfg, nil); err != nil {
+					return err
+				}
+			}
+			mediaPaths, err := findMedia(args)
+			if err != nil {
+				return err
+			}
+
+			var aligned, skipped, noTranscript int
+			for _, mediaPath := range mediaPaths {
+				basePath := strings.TrimSuffix(mediaPath, path.Ext(mediaPath))
+				subtitlePath := subtitle.Find(basePath)
+				if subtitlePath == "" {
+					continue
+				}
+				// lines are not limited since the subtitles are re-written.
+				dialog, err := subtitle.ReadFile(subtitlePath, false, time.Duration(math.MaxInt64))
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", subtitlePath, err)
+				}
+				// other formats are re-written as an SRT which the importer will prefer, leaving the original as is.
+				srtPath := basePath + ".srt"
+
+				transcript, err := loadTranscript(mediaPath, srtPath)
+				if err != nil {
+					return fmt.Errorf("failed to load transcript for %s: %w", mediaPath, err)
+				}
+				if transcript == nil && client != nil {
+					logger.Info("Transcribing...", slog.String("i", mediaPath), slog.String("backend", backend))
+					if transcript, err = client.Transcribe(context.Background(), mediaPath); err != nil {
+						return fmt.Errorf("failed to transcribe %s: %w", mediaPath, err)
+					}
+				}
+				if transcript == nil {
+					noTranscript++
+					continue
+				}
+
+				result := transcriber.Align(dialog, *transcript, align)
+
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n", subtitlePath)
+				if err := result.WriteReport(cmd.OutOrStdout()); err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout())
+
+				if result.MatchedFraction() < align.MinMatched {
+					logger.Warn("Too few lines matched the transcript, the subtitles may be for different media", slog.String("i", subtitlePath))
+					skipped++
+					continue
+				}
+				if !dryRun {
+					if subtitlePath == srtPath {
+						if err := srt.Backup(srtPath); err != nil {
+							return err
+						}
+					}
+					if err := srt.WriteFile(result.Dialog, srtPath); err != nil {
+						return fmt.Errorf("failed to write %s: %w", srtPath, err)
+					}
+				}
+				aligned++
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Aligned:       %d\n", aligned)
+			fmt.Fprintf(cmd.OutOrStdout(), "Skipped:       %d\n", skipped)
+			fmt.Fprintf(cmd.OutOrStdout(), "No transcript: %d\n", noTranscript)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&backend, "backend", "", "transcribe media without a cached transcript using this backend: assemblyai or whisper")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report the drift without re-writing any SRTs")
+	cfg.RegisterFlags(cmd.Flags(), "")
+	align.RegisterFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,83 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/audiometa"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NewBatchCommand creates SRTs for every media file in the given directories or globs e.g.
+// transcribe batch var/media "var/other/*.mp3"
+func NewBatchCommand(logger *slog.Logger) *cobra.Command {
+	var (
+		backend    string
+		jobs       int
+		retries    int
+		retryDelay time.Duration
+		cfg        = &transcriber.Config{}
+		segment    = transcriber.DefaultSegmentOptions()
+		vocabFlags = &vocabularyFlags{}
+	)
+	cmd := &cobra.Command{
+		Use:   "batch [dir|glob...]",
+		Short: "Transcribe all media files that do not already have subtitles, re-using cached transcripts",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			vocab, err := vocabFlags.load()
+			if err != nil {
+				return err
+			}
+			client, err := transcriber.New(logger, backend, cfg, vocab)
+			if err != nil {
+				return err
+			}
+			mediaPaths, err := findMedia(args)
+			if err != nil {
+				return err
+			}
+
+			logger.Info("Starting batch...", slog.Int("files", len(mediaPaths)), slog.String("backend", backend), slog.Int("jobs", jobs))
+			result := transcriber.Batch(context.Background(), logger, client, mediaPaths, transcriber.BatchOptions{
+				Jobs:       jobs,
+				Retries:    retries,
+				RetryDelay: retryDelay,
+				Segment:    segment,
+			})
+			fmt.Fprint(cmd.OutOrStdout(), result.String())
+
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("%d files failed", len(result.Failed))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&backend, "backend", transcriber.BackendAssemblyAI, "transcription backend: assemblyai or whisper")
+	cmd.Flags().IntVar(&jobs, "jobs", 4, "number of files to transcribe concurrently")
+	cmd.Flags().IntVar(&retries, "retries", 2, "number of times to retry a file that failed with a transient error e.g. rate limiting")
+	cmd.Flags().DurationVar(&retryDelay, "retry-delay", time.Second*10, "delay before the first retry, doubled for each subsequent retry")
+	cfg.RegisterFlags(cmd.Flags(), "")
+	segment.RegisterFlags(cmd.Flags())
+	vocabFlags.register(cmd.Flags())
+
+	return cmd
+}
+
+// findMedia expands the args to a sorted list of media files. Each arg may be a directory which is searched recursively,
+// or a glob.
+func findMedia(args []string) ([]string, error) {
+	return util.FindFiles(args, isMedia)
+}
+
+func isMedia(filePath string) bool {
+	return slices.Contains(audiometa.DefaultMediaExtensions, strings.ToLower(path.Ext(filePath)))
+}
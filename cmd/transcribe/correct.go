@@ -0,0 +1,109 @@
+package transcribe
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/warmans/audio-search-bot/internal/assemblyai"
+	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"github.com/warmans/audio-search-bot/internal/subtitle"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+)
+
+// NewCorrectCommand re-applies the vocabulary spelling to existing transcripts and re-writes the SRTs without
+// transcribing the media again. The original SRT is kept with a .orig extension in the same way as align.
+func NewCorrectCommand(logger *slog.Logger) *cobra.Command {
+	var (
+		dryRun     bool
+		segment    = transcriber.DefaultSegmentOptions()
+		vocabFlags = &vocabularyFlags{}
+	)
+	cmd := &cobra.Command{
+		Use:   "correct [dir|glob...]",
+		Short: "Apply the vocabulary spelling to cached transcripts and re-write the SRTs, keeping the originals as .orig",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			vocab, err := vocabFlags.load()
+			if err != nil {
+				return err
+			}
+			if vocab == nil {
+				return fmt.Errorf("no vocabulary-path was given")
+			}
+			mediaPaths, err := findMedia(args)
+			if err != nil {
+				return err
+			}
+
+			var corrected, replaced, uncached, skipped int
+			for _, mediaPath := range mediaPaths {
+				basePath := strings.TrimSuffix(mediaPath, path.Ext(mediaPath))
+				srtPath := basePath + ".srt"
+				// other formats were not transcribed so writing an SRT would replace them in the index.
+				if subtitlePath := subtitle.Find(basePath); subtitlePath != "" && subtitlePath != srtPath {
+					logger.Info("Skipping media with non-SRT subtitles", slog.String("i", subtitlePath))
+					skipped++
+					continue
+				}
+
+				transcript, err := loadTranscript(mediaPath, srtPath)
+				if err != nil {
+					return fmt.Errorf("failed to load transcript for %s: %w", mediaPath, err)
+				}
+				if transcript == nil {
+					uncached++
+					continue
+				}
+				mediaVocab, err := vocab.ForMedia(mediaPath)
+				if err != nil {
+					return err
+				}
+				num := mediaVocab.Correct(transcript)
+				if num == 0 {
+					continue
+				}
+				logger.Info("Correcting...", slog.String("i", mediaPath), slog.Int("replaced", num), slog.Bool("dry_run", dryRun))
+				if !dryRun {
+					if _, err := os.Stat(srtPath); err == nil {
+						if err := srt.Backup(srtPath); err != nil {
+							return err
+						}
+					}
+					if err := transcriber.WriteTranscript(transcript, srtPath, segment); err != nil {
+						return fmt.Errorf("failed to write %s: %w", srtPath, err)
+					}
+				}
+				corrected++
+				replaced += num
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Corrected:   %d\n", corrected)
+			fmt.Fprintf(cmd.OutOrStdout(), "Replaced:    %d\n", replaced)
+			fmt.Fprintf(cmd.OutOrStdout(), "No cache:    %d\n", uncached)
+			fmt.Fprintf(cmd.OutOrStdout(), "Skipped:     %d\n", skipped)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report the corrections without re-writing any SRTs")
+	segment.RegisterFlags(cmd.Flags())
+	vocabFlags.register(cmd.Flags())
+
+	return cmd
+}
+
+// loadTranscript prefers the original AssemblyAI response but falls back to the words written with the SRT
+// for other backends.
+func loadTranscript(mediaPath string, srtPath string) (*model.Transcript, error) {
+	transcript, err := assemblyai.LoadCached(mediaPath)
+	if err != nil || transcript != nil {
+		return transcript, err
+	}
+	return metadata.LoadWords(metadata.WordsPath(srtPath))
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/spf13/cobra"
-	"github.com/warmans/audio-search-bot/internal/assemblyai"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
 	"log/slog"
-	"os"
+	"path"
+	"strings"
 )
 
 func NewRootCommand(logger *slog.Logger) *cobra.Command {
@@ -17,35 +18,54 @@ func NewRootCommand(logger *slog.Logger) *cobra.Command {
 	}
 
 	cmd.AddCommand(NewMP3Command(logger))
+	cmd.AddCommand(NewBatchCommand(logger))
+	cmd.AddCommand(NewCorrectCommand(logger))
+	cmd.AddCommand(NewAlignCommand(logger))
 
 	return cmd
 }
 
 // NewMP3Command
-// for this you must extract the mp3 from the video file e.g.
+// for the assemblyai backend you must extract the mp3 from the video file e.g.
 // ffmpeg -i foo.mp4 foo.mp3
 func NewMP3Command(logger *slog.Logger) *cobra.Command {
 	var (
 		mp3Path       string
 		outputSRTPath string
+		backend       string
+		cfg           = &transcriber.Config{}
+		segment       = transcriber.DefaultSegmentOptions()
+		vocabFlags    = &vocabularyFlags{}
 	)
 	cmd := &cobra.Command{
 		Use:   "mp3",
 		Short: "Transcribe an mp3 to srt",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			assemblyAiKey := os.Getenv("ASSEMBLY_AI_ACCESS_TOKEN")
-			if assemblyAiKey == "" {
-				return fmt.Errorf("ASSEMBLY_AI_ACCESS_TOKEN not set")
+			vocab, err := vocabFlags.load()
+			if err != nil {
+				return err
+			}
+			client, err := transcriber.New(logger, backend, cfg, vocab)
+			if err != nil {
+				return err
+			}
+			if outputSRTPath == "" {
+				outputSRTPath = fmt.Sprintf("%s.srt", strings.TrimSuffix(mp3Path, path.Ext(mp3Path)))
 			}
 
-			client := assemblyai.NewClient(logger, assemblyAiKey)
-			return client.Transcribe(context.Background(), mp3Path, outputSRTPath)
+			logger.Info("Transcribing...", slog.String("i", mp3Path), slog.String("o", outputSRTPath), slog.String("backend", backend))
+			_, err = transcriber.WriteSRT(context.Background(), client, mp3Path, outputSRTPath, segment)
+			return err
 		},
 	}
 
 	cmd.Flags().StringVar(&mp3Path, "i", "", "path to input MP3")
-	cmd.Flags().StringVar(&outputSRTPath, "o", fmt.Sprintf("%s.srt", mp3Path), "path to dump SRT")
+	cmd.Flags().StringVar(&outputSRTPath, "o", "", "path to dump SRT (defaults to the input path with a .srt extension)")
+	cmd.Flags().StringVar(&backend, "backend", transcriber.BackendAssemblyAI, "transcription backend: assemblyai or whisper")
+	cfg.RegisterFlags(cmd.Flags(), "")
+	segment.RegisterFlags(cmd.Flags())
+	vocabFlags.register(cmd.Flags())
 
 	return cmd
 }
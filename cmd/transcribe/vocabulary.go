@@ -0,0 +1,29 @@
+package transcribe
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/vocabulary"
+)
+
+type vocabularyFlags struct {
+	vocabularyPath   string
+	filePatternsPath string
+}
+
+func (f *vocabularyFlags) register(fs *pflag.FlagSet) {
+	fs.StringVar(&f.vocabularyPath, "vocabulary-path", "", "path to a dir of <publication>.json vocabularies used to improve transcription")
+	fs.StringVar(&f.filePatternsPath, "file-patterns-path", "", "path to a JSON file describing how the publication is extracted from file names")
+}
+
+// load returns nil if no vocabulary path was given. Only the file name is used to find the publication.
+func (f *vocabularyFlags) load() (*vocabulary.Set, error) {
+	if f.vocabularyPath == "" {
+		return nil, nil
+	}
+	patterns, err := metadata.LoadFilePatterns(f.filePatternsPath)
+	if err != nil {
+		return nil, err
+	}
+	return vocabulary.NewSet(f.vocabularyPath, patterns, ""), nil
+}
@@ -6,67 +6,124 @@ import (
 	"fmt"
 	aai "github.com/AssemblyAI/assemblyai-go-sdk"
 	"github.com/pkg/errors"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"github.com/warmans/audio-search-bot/internal/vocabulary"
 	"log/slog"
+	"net/http"
 	"os"
+	"time"
 )
 
-func NewClient(logger *slog.Logger, apiKey string) *Client {
+// NewClient creates a client for AssemblyAI. The vocabulary is optional.
+func NewClient(logger *slog.Logger, apiKey string, vocab *vocabulary.Set) *Client {
 	return &Client{
 		apiKey: apiKey,
 		logger: logger,
+		vocab:  vocab,
 	}
 }
 
 type Client struct {
 	apiKey string
 	logger *slog.Logger
+	vocab  *vocabulary.Set
 }
 
-func (c *Client) Transcribe(ctx context.Context, mp3Path string, outputPath string) error {
+func (c *Client) Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error) {
 
 	client := aai.NewClient(c.apiKey)
 
-	// transcript parameters where speaker_labels has been enabled
+	// transcript parameters where speaker_labels and auto_chapters have been enabled. Transcripts cached before
+	// chapters were requested will not have any.
 	params := &aai.TranscriptOptionalParams{
 		SpeakerLabels: aai.Bool(true),
+		AutoChapters:  aai.Bool(true),
 	}
+	vocab, err := c.vocab.ForMedia(mediaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vocabulary: %w", err)
+	}
+	applyVocabulary(params, vocab)
 
-	mp3, err := os.Open(mp3Path)
+	media, err := os.Open(mediaPath)
 	if err != nil {
-		return fmt.Errorf("failed to open mp3: %w", err)
+		return nil, fmt.Errorf("failed to open media: %w", err)
 	}
-	defer mp3.Close()
+	defer media.Close()
 
-	var cachePath = fmt.Sprintf("%s.json", mp3Path)
+	var cachePath = CachePath(mediaPath)
 	var transcript *aai.Transcript
-	if transcript, err = c.getCached(cachePath); err != nil {
-		return err
+	if transcript, err = readCache(cachePath); err != nil {
+		return nil, err
 	}
 
 	if transcript == nil {
-		c.logger.Info("No Cache, submitting job...", slog.String("i", mp3Path), slog.String("cache_path", cachePath))
-		newTranscript, err := client.Transcripts.TranscribeFromReader(ctx, mp3, params)
+		c.logger.Info("No Cache, submitting job...", slog.String("i", mediaPath), slog.String("cache_path", cachePath))
+		newTranscript, err := client.Transcripts.TranscribeFromReader(ctx, media, params)
 		if err != nil {
-			return fmt.Errorf("transcription failed: %w", err)
+			return nil, fmt.Errorf("transcription failed: %w", markTransient(err))
 		}
 		transcript = &newTranscript
 
-		if err := c.dumpCache(mp3Path, transcript); err != nil {
-			return err
+		if err := c.dumpCache(mediaPath, transcript); err != nil {
+			return nil, err
 		}
 	}
 
-	c.logger.Info("Converting result to SRT...", slog.String("o", outputPath))
+	return toTranscript(transcript), nil
+}
 
-	outputSRT, err := os.Create(outputPath)
-	if err != nil {
-		return err
+// applyVocabulary asks AssemblyAI to boost and re-spell the vocabulary. The spelling is also applied to the
+// transcript afterward since cached transcripts may pre-date the vocabulary.
+func applyVocabulary(params *aai.TranscriptOptionalParams, vocab *vocabulary.Vocabulary) {
+	if vocab == nil {
+		return
+	}
+	if len(vocab.WordBoost) > 0 {
+		params.WordBoost = vocab.WordBoost
+		if vocab.BoostParam != "" {
+			params.BoostParam = aai.TranscriptBoostParam(vocab.BoostParam)
+		}
+	}
+	for _, v := range vocab.Spelling {
+		params.CustomSpelling = append(params.CustomSpelling, aai.TranscriptCustomSpelling{From: v.From, To: aai.String(v.To)})
 	}
-	defer outputSRT.Close()
+}
 
-	return ToSrt(*transcript, outputSRT)
+// LoadCached returns the cached transcript of the media without contacting AssemblyAI. Nil is returned if
+// the media was never transcribed.
+func LoadCached(mediaPath string) (*model.Transcript, error) {
+	transcript, err := readCache(CachePath(mediaPath))
+	if err != nil || transcript == nil {
+		return nil, err
+	}
+	return toTranscript(transcript), nil
 }
-func (c *Client) getCached(cachePath string) (*aai.Transcript, error) {
+
+func toTranscript(raw *aai.Transcript) *model.Transcript {
+	transcript := &model.Transcript{Words: make([]model.TranscriptWord, 0, len(raw.Words))}
+	for _, v := range raw.Words {
+		transcript.Words = append(transcript.Words, model.TranscriptWord{
+			Text:       util.FromPtr(v.Text),
+			Start:      time.Duration(util.FromPtr(v.Start)) * time.Millisecond,
+			End:        time.Duration(util.FromPtr(v.End)) * time.Millisecond,
+			Confidence: util.FromPtr(v.Confidence),
+			Speaker:    util.FromPtr(v.Speaker),
+		})
+	}
+	for _, v := range raw.Chapters {
+		transcript.Chapters = append(transcript.Chapters, model.Chapter{
+			Headline: util.FromPtr(v.Headline),
+			Summary:  util.FromPtr(v.Summary),
+			Start:    time.Duration(util.FromPtr(v.Start)) * time.Millisecond,
+			End:      time.Duration(util.FromPtr(v.End)) * time.Millisecond,
+		})
+	}
+	return transcript
+}
+
+func readCache(cachePath string) (*aai.Transcript, error) {
 	f, err := os.Open(cachePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -84,8 +141,42 @@ func (c *Client) getCached(cachePath string) (*aai.Transcript, error) {
 	return transcript, nil
 }
 
-func (c *Client) dumpCache(mp3Path string, transcript *aai.Transcript) error {
-	f, err := os.Create(fmt.Sprintf("%s.json", mp3Path))
+// Cached is true if the media has already been transcribed.
+func (c *Client) Cached(mediaPath string) bool {
+	_, err := os.Stat(CachePath(mediaPath))
+	return err == nil
+}
+
+// transientError is an API error that may succeed if retried.
+type transientError struct {
+	error
+}
+
+func (e transientError) Temporary() bool {
+	return true
+}
+
+func (e transientError) Unwrap() error {
+	return e.error
+}
+
+// markTransient marks rate limiting and server errors as transient. Network errors are already identified by
+// the net package.
+func markTransient(err error) error {
+	var apiErr aai.APIError
+	if errors.As(err, &apiErr) && (apiErr.Status == http.StatusTooManyRequests || apiErr.Status >= http.StatusInternalServerError) {
+		return transientError{err}
+	}
+	return err
+}
+
+// CachePath is where the raw transcript for the given media is stored to avoid paying for it twice.
+func CachePath(mediaPath string) string {
+	return fmt.Sprintf("%s.json", mediaPath)
+}
+
+func (c *Client) dumpCache(mediaPath string, transcript *aai.Transcript) error {
+	f, err := os.Create(CachePath(mediaPath))
 	if err != nil {
 		return err
 	}
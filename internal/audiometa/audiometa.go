@@ -7,10 +7,22 @@ import (
 	ffmpeg_go "github.com/warmans/ffmpeg-go"
 	"os"
 	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// DefaultMediaExtensions are the media types that will be used for an SRT in order of preference.
+var DefaultMediaExtensions = []string{".mp3", ".m4a", ".ogg", ".opus", ".flac", ".wav", ".mp4", ".mkv"}
+
+var videoExtensions = []string{".mp4", ".mkv"}
+
+// IsVideo is true if the media is expected to have a video stream.
+func IsVideo(mediaPath string) bool {
+	return slices.Contains(videoExtensions, strings.ToLower(path.Ext(mediaPath)))
+}
+
 type ProbeStream struct {
 	Index          int    `json:"index"`
 	CodecName      string `json:"codec_name"`
@@ -65,32 +77,74 @@ type ProbeResult struct {
 	Format  ProbeFormat   `json:"format"`
 }
 
-func DumpMeta(audioFilePath string) error {
+// HasArtwork is true if the media has an embedded cover image. Video frames are not considered artwork.
+func (p *ProbeResult) HasArtwork() bool {
+	if IsVideo(p.Format.Filename) {
+		return false
+	}
+	for _, v := range p.Streams {
+		if v.CodecName == "png" || v.CodecName == "mjpeg" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ProbeResult) Duration() time.Duration {
+	seconds, err := strconv.ParseFloat(p.Format.Duration, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// TagMap returns all non-empty tags keyed by their ffprobe name.
+func (t ProbeTags) TagMap() map[string]string {
+	tags := map[string]string{}
+	for k, v := range map[string]string{
+		"track":                 t.Track,
+		"replaygain_track_gain": t.ReplaygainTrackGain,
+		"replaygain_track_peak": t.ReplaygainTrackPeak,
+		"title":                 t.Title,
+		"album":                 t.Album,
+		"album_artist":          t.AlbumArtist,
+		"artist":                t.Artist,
+		"genre":                 t.Genre,
+		"date":                  t.Date,
+	} {
+		if v != "" {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// ArtworkPath is the path DumpMeta writes any embedded image to.
+func ArtworkPath(audioFilePath string) string {
+	return fmt.Sprintf("%s.png", strings.TrimSuffix(audioFilePath, path.Ext(audioFilePath)))
+}
+
+func DumpMeta(audioFilePath string) (*ProbeResult, error) {
 	result, err := ExtractMeta(audioFilePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, v := range result.Streams {
-		if v.CodecName == "png" {
-			imageFileName := fmt.Sprintf("%s.png", strings.TrimSuffix(audioFilePath, path.Ext(audioFilePath)))
-			err := DumpImage(audioFilePath, imageFileName)
-			if err != nil {
-				return err
-			}
-			break
+	if result.HasArtwork() {
+		if err := DumpImage(audioFilePath, ArtworkPath(audioFilePath)); err != nil {
+			return nil, err
 		}
 	}
 
 	outputFileName := fmt.Sprintf("%s.meta.json", strings.TrimSuffix(audioFilePath, path.Ext(audioFilePath)))
 	f, err := os.Create(outputFileName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer f.Close()
 
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	return enc.Encode(result.Format.Tags)
+	return result, enc.Encode(result.Format.Tags)
 }
 
 func DumpImage(audioFilePath string, outputImagePath string) error {
@@ -101,7 +155,7 @@ func DumpImage(audioFilePath string, outputImagePath string) error {
 	}
 	return ffmpeg_go.
 		Input(audioFilePath, ffmpeg_go.KwArgs{}).
-		Output(outputImagePath).
+		Output(outputImagePath, ffmpeg_go.KwArgs{"frames:v": 1}).
 		Run()
 }
 
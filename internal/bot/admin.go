@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// discord messages are limited to 2000 characters.
+const maxMessageLength = 2000
+
+// adminCommand is only visible to server administrators.
+var adminCommand = &discordgo.ApplicationCommand{
+	Name:                     "supertalk-admin",
+	Description:              "Manage the bot",
+	Type:                     discordgo.ChatApplicationCommand,
+	DefaultMemberPermissions: util.ToPtr(int64(discordgo.PermissionAdministrator)),
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        "status",
+			Description: "Show the import status",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:        "quarantine",
+			Description: "List files that failed to import",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:        "retry",
+			Description: "Retry a quarantined file on the next sync",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "srt_file",
+					Description: "SRT file path as shown in the quarantine list",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "timing",
+			Description: "Show or correct the timing of an episode's dialog (e.g. if the subtitles are out of sync)",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "media_id",
+					Description: "Episode ID e.g. xfm-S01E02",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+				},
+				{
+					Name:        "offset",
+					Description: "Added to all timestamps e.g. -1.5s",
+					Type:        discordgo.ApplicationCommandOptionString,
+				},
+				{
+					Name:        "scale",
+					Description: "Timestamps are multiplied by this before the offset is added e.g. 1.0427 (25/23.976 fps)",
+					Type:        discordgo.ApplicationCommandOptionNumber,
+				},
+			},
+		},
+	},
+}
+
+func (b *Bot) adminBegin(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		b.respondError(s, i, fmt.Errorf("no sub-command given"))
+		return
+	}
+	switch subCommand := data.Options[0]; subCommand.Name {
+	case "status":
+		b.showImportStatus(s, i)
+	case "quarantine":
+		b.listQuarantined(s, i)
+	case "retry":
+		b.retryQuarantined(s, i, subCommand.Options[0].StringValue())
+	case "timing":
+		b.setTiming(s, i, subCommand.Options)
+	default:
+		b.respondError(s, i, fmt.Errorf("unknown sub-command: %s", subCommand.Name))
+	}
+}
+
+func (b *Bot) showImportStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	status, err := b.importStatus.Status(context.Background())
+	if err != nil {
+		b.respondError(s, i, fmt.Errorf("failed to get import status: %w", err))
+		return
+	}
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "**Phase:** %s\n", status.Phase)
+	fmt.Fprintf(sb, "**Queued:** %d **Done:** %d **Failed:** %d\n", status.Queued, status.Done, status.Failed)
+	fmt.Fprintf(sb, "**Indexed dialog:** %d\n", status.IndexDocumentCount)
+	if status.SkippedBlocks > 0 {
+		fmt.Fprintf(sb, "**Skipped subtitle blocks:** %d (last in `%s`)\n", status.SkippedBlocks, status.LastSkippedFile)
+	}
+	if !status.LastSync.IsZero() {
+		fmt.Fprintf(sb, "**Last sync:** <t:%d:R>\n", status.LastSync.Unix())
+	}
+	if status.LastError != "" {
+		fmt.Fprintf(sb, "**Last error** (<t:%d:R>): %s\n", status.LastErrorTime.Unix(), util.TrimToN(status.LastError, 500))
+	}
+	b.respondEphemeral(s, i, util.TrimToN(sb.String(), maxMessageLength))
+}
+
+func (b *Bot) listQuarantined(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	failures, err := b.importFailures.ListImportFailures()
+	if err != nil {
+		b.respondError(s, i, fmt.Errorf("failed to list quarantined files: %w", err))
+		return
+	}
+	if len(failures) == 0 {
+		b.respondEphemeral(s, i, "No files are quarantined.")
+		return
+	}
+	sb := &strings.Builder{}
+	for _, v := range failures {
+		fmt.Fprintf(
+			sb,
+			"`%s` attempts: %d, next attempt: %s\n> %s\n",
+			v.SRTFile,
+			v.Attempts,
+			v.NextAttempt.Format(time.RFC3339),
+			util.TrimToN(v.Error, 200),
+		)
+	}
+	b.respondEphemeral(s, i, util.TrimToN(sb.String(), maxMessageLength))
+}
+
+func (b *Bot) retryQuarantined(s *discordgo.Session, i *discordgo.InteractionCreate, srtFile string) {
+	if err := b.importFailures.RetryImportFailure(srtFile); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			b.respondError(s, i, fmt.Errorf("%s is not quarantined", srtFile))
+			return
+		}
+		b.respondError(s, i, fmt.Errorf("failed to retry file: %w", err))
+		return
+	}
+	b.respondEphemeral(s, i, fmt.Sprintf("`%s` will be retried on the next sync.", srtFile))
+}
+
+// setTiming shows the current timing correction if only the media ID is given, otherwise it updates the given
+// parts of the correction, keeping the rest.
+func (b *Bot) setTiming(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var mediaID string
+	var offset *time.Duration
+	var scale *float64
+	for _, opt := range options {
+		switch opt.Name {
+		case "media_id":
+			mediaID = opt.StringValue()
+		case "offset":
+			val, err := time.ParseDuration(opt.StringValue())
+			if err != nil {
+				b.respondError(s, i, fmt.Errorf("offset was not a valid duration: %w", err))
+				return
+			}
+			offset = &val
+		case "scale":
+			val := opt.FloatValue()
+			if val <= 0 {
+				b.respondError(s, i, fmt.Errorf("scale must be greater than 0"))
+				return
+			}
+			scale = &val
+		}
+	}
+	ep, err := b.srtStore.GetEpisode(mediaID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			b.respondError(s, i, fmt.Errorf("unknown episode: %s", mediaID))
+			return
+		}
+		b.respondError(s, i, fmt.Errorf("failed to get episode: %w", err))
+		return
+	}
+	correction := ep.Timing
+	if offset != nil || scale != nil {
+		if offset != nil {
+			correction.Offset = *offset
+		}
+		if scale != nil {
+			correction.Scale = *scale
+		}
+		if err := b.srtStore.SetTimingCorrection(mediaID, correction); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				b.respondError(s, i, fmt.Errorf("unknown episode: %s", mediaID))
+				return
+			}
+			b.respondError(s, i, fmt.Errorf("failed to set timing: %w", err))
+			return
+		}
+	}
+	displayScale := correction.Scale
+	if displayScale == 0 {
+		displayScale = 1
+	}
+	b.respondEphemeral(s, i, fmt.Sprintf("`%s` timing offset: `%s` scale: `%g`", mediaID, correction.Offset, displayScale))
+}
+
+func (b *Bot) respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("failed to respond", slog.String("err", err.Error()))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
+	"github.com/warmans/audio-search-bot/internal/audiometa"
 	"github.com/warmans/audio-search-bot/internal/model"
 	"github.com/warmans/audio-search-bot/internal/search"
 	"github.com/warmans/audio-search-bot/internal/searchterms"
@@ -28,21 +29,30 @@ var punctuation = regexp.MustCompile(`[^a-zA-Z0-9\s]+`)
 var spaces = regexp.MustCompile(`[\s]{2,}`)
 var metaWhitespace = regexp.MustCompile(`[\n\r\t]+`)
 
+// ImportStatusProvider reports the progress of the importer for the admin command.
+type ImportStatusProvider interface {
+	Status(ctx context.Context) (model.ImportStatus, error)
+}
+
 func NewBot(
 	logger *slog.Logger,
 	session *discordgo.Session,
 	guildID string,
 	searcher search.Searcher,
-	srtStore *store.SRTStore,
+	srtStore store.DialogStore,
+	importFailures store.ImportFailureStore,
+	importStatus ImportStatusProvider,
 	mediaPath string,
 ) *Bot {
 	bot := &Bot{
-		logger:    logger,
-		session:   session,
-		guildID:   guildID,
-		searcher:  searcher,
-		mediaPath: mediaPath,
-		srtStore:  srtStore,
+		logger:         logger,
+		session:        session,
+		guildID:        guildID,
+		searcher:       searcher,
+		mediaPath:      mediaPath,
+		srtStore:       srtStore,
+		importFailures: importFailures,
+		importStatus:   importStatus,
 		commands: []*discordgo.ApplicationCommand{
 			{
 				Name:        "supertalk",
@@ -58,10 +68,12 @@ func NewBot(
 					},
 				},
 			},
+			adminCommand,
 		},
 	}
 	bot.commandHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
-		"supertalk": bot.queryBegin,
+		"supertalk":       bot.queryBegin,
+		"supertalk-admin": bot.adminBegin,
 	}
 	bot.buttonHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate, suffix string){
 		"cfm": bot.queryComplete,
@@ -78,7 +90,9 @@ type Bot struct {
 	searcher        search.Searcher
 	mediaPath       string
 	guildID         string
-	srtStore        *store.SRTStore
+	srtStore        store.DialogStore
+	importFailures  store.ImportFailureStore
+	importStatus    ImportStatusProvider
 	commands        []*discordgo.ApplicationCommand
 	commandHandlers map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate)
 	buttonHandlers  map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate, customIdPayload string)
@@ -193,14 +207,19 @@ func (b *Bot) queryBegin(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			b.logger.Error("Failed to fetch autocomplete options", slog.String("err", err.Error()))
 			return
 		}
+		phrase := searchPhrase(terms)
 		var choices []*discordgo.ApplicationCommandOptionChoice
 		for _, v := range res {
+			// clips start at the phrase the user searched for if the line has word timestamps.
+			trimStart, trimEnd := b.phraseTrimLine(v.MediaID, v.Pos, phrase)
 			payload, err := json.Marshal(CustomID{
 				MediaID:         v.MediaID,
 				StartLine:       v.Pos,
 				EndLine:         v.Pos,
 				ContentModifier: ContentModifierNone,
 				MediaType:       MediaTypeNone,
+				TrimStartWords:  trimStart,
+				TrimEndWords:    trimEnd,
 			})
 			if err != nil {
 				b.logger.Error("failed to marshal result", slog.String("err", err.Error()))
@@ -279,7 +298,15 @@ func (b *Bot) sendPreview(
 		username = i.Member.DisplayName()
 	}
 
-	interactionResponse, err := b.mediaResponse(customID, username)
+	dialog, err := b.selectDialog(customID)
+	if err != nil {
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: util.ToPtr("ERROR: Failed to fetch dialog"),
+		})
+		return err
+	}
+
+	interactionResponse, err := b.mediaResponse(customID, dialog, username)
 	if err != nil {
 		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: util.ToPtr("ERROR: Failed to create media"),
@@ -287,7 +314,7 @@ func (b *Bot) sendPreview(
 		return err
 	}
 
-	interactionResponse.Components = b.buttons(customID)
+	interactionResponse.Components = b.buttons(customID, dialog)
 	interactionResponse.Flags = discordgo.MessageFlagsEphemeral
 
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -345,7 +372,7 @@ func (b *Bot) queryComplete(s *discordgo.Session, i *discordgo.InteractionCreate
 	}
 }
 
-func (b *Bot) buttons(customID CustomID) []discordgo.MessageComponent {
+func (b *Bot) buttons(customID CustomID, dialog []model.Dialog) []discordgo.MessageComponent {
 
 	postButtons := []discordgo.MessageComponent{
 		discordgo.Button{
@@ -526,6 +553,80 @@ func (b *Bot) buttons(customID CustomID) []discordgo.MessageComponent {
 		})
 	}
 
+	wordRow := []discordgo.MessageComponent{}
+	if len(dialog[0].Words) > 1 {
+		wordRow = append(wordRow, discordgo.Button{
+			// Label is what the user will see on the button.
+			Label: "Trim First Word",
+			Emoji: &discordgo.ComponentEmoji{
+				Name: "✂",
+			},
+			// Style provides coloring of the button. There are not so many styles tho.
+			Style: discordgo.SecondaryButton,
+			// CustomID is a thing telling Discord which data to send when this button will be pressed.
+			CustomID: encodeCustomIDForAction(
+				"up",
+				customID.withOption(
+					withTrimStartWords(customID.TrimStartWords+1),
+				),
+			),
+		})
+	}
+	if customID.TrimStartWords > 0 {
+		wordRow = append(wordRow, discordgo.Button{
+			// Label is what the user will see on the button.
+			Label: "Restore First Word",
+			Emoji: &discordgo.ComponentEmoji{
+				Name: "↩",
+			},
+			// Style provides coloring of the button. There are not so many styles tho.
+			Style: discordgo.SecondaryButton,
+			// CustomID is a thing telling Discord which data to send when this button will be pressed.
+			CustomID: encodeCustomIDForAction(
+				"up",
+				customID.withOption(
+					withTrimStartWords(customID.TrimStartWords-1),
+				),
+			),
+		})
+	}
+	if len(dialog[len(dialog)-1].Words) > 1 {
+		wordRow = append(wordRow, discordgo.Button{
+			// Label is what the user will see on the button.
+			Label: "Trim Last Word",
+			Emoji: &discordgo.ComponentEmoji{
+				Name: "✂",
+			},
+			// Style provides coloring of the button. There are not so many styles tho.
+			Style: discordgo.SecondaryButton,
+			// CustomID is a thing telling Discord which data to send when this button will be pressed.
+			CustomID: encodeCustomIDForAction(
+				"up",
+				customID.withOption(
+					withTrimEndWords(customID.TrimEndWords+1),
+				),
+			),
+		})
+	}
+	if customID.TrimEndWords > 0 {
+		wordRow = append(wordRow, discordgo.Button{
+			// Label is what the user will see on the button.
+			Label: "Restore Last Word",
+			Emoji: &discordgo.ComponentEmoji{
+				Name: "↪",
+			},
+			// Style provides coloring of the button. There are not so many styles tho.
+			Style: discordgo.SecondaryButton,
+			// CustomID is a thing telling Discord which data to send when this button will be pressed.
+			CustomID: encodeCustomIDForAction(
+				"up",
+				customID.withOption(
+					withTrimEndWords(customID.TrimEndWords-1),
+				),
+			),
+		})
+	}
+
 	buttons := []discordgo.MessageComponent{}
 	if len(editRow1) > 0 {
 		buttons = append(buttons, discordgo.ActionsRow{
@@ -537,6 +638,11 @@ func (b *Bot) buttons(customID CustomID) []discordgo.MessageComponent {
 			Components: editRow2,
 		})
 	}
+	if len(wordRow) > 0 {
+		buttons = append(buttons, discordgo.ActionsRow{
+			Components: wordRow,
+		})
+	}
 	if len(postButtons) > 0 {
 		buttons = append(buttons, discordgo.ActionsRow{
 			Components: postButtons,
@@ -546,11 +652,25 @@ func (b *Bot) buttons(customID CustomID) []discordgo.MessageComponent {
 	return buttons
 }
 
-func (b *Bot) mediaResponse(
-	customID CustomID,
-	username string,
-) (*discordgo.InteractionResponseData, error) {
+// phraseTrimLine finds the words to trim from the line so that it starts and ends with the phrase. Failing to
+// find the words should not prevent the result being shown so errors are only logged.
+func (b *Bot) phraseTrimLine(mediaID string, pos int32, phrase []string) (int32, int32) {
+	if len(phrase) == 0 {
+		return 0, 0
+	}
+	dialog, err := b.srtStore.GetDialogRange(mediaID, pos, pos)
+	if err != nil {
+		b.logger.Error("Failed to fetch words", slog.String("media_id", mediaID), slog.String("err", err.Error()))
+		return 0, 0
+	}
+	if len(dialog) == 0 {
+		return 0, 0
+	}
+	return phraseTrim(dialog[0].Words, phrase)
+}
 
+// selectDialog fetches the selected lines, trimmed to the selected words.
+func (b *Bot) selectDialog(customID CustomID) ([]model.Dialog, error) {
 	dialog, err := b.srtStore.GetDialogRange(customID.MediaID, customID.StartLine, customID.EndLine)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch selected lines: %s", customID.String())
@@ -558,6 +678,14 @@ func (b *Bot) mediaResponse(
 	if len(dialog) == 0 {
 		return nil, fmt.Errorf("no dialog was selected")
 	}
+	return trimWords(dialog, customID.TrimStartWords, customID.TrimEndWords), nil
+}
+
+func (b *Bot) mediaResponse(
+	customID CustomID,
+	dialog []model.Dialog,
+	username string,
+) (*discordgo.InteractionResponseData, error) {
 
 	dialogFormatted := strings.Builder{}
 	for _, d := range dialog {
@@ -593,14 +721,19 @@ func (b *Bot) mediaResponse(
 	if customID.ContentModifier == ContentModifierDisableText {
 		content = fmt.Sprintf("Posted by %s", username)
 	} else {
+		var chapter string
+		if headline := b.chapterHeadline(customID.MediaID, dialog[0].StartTimestamp); headline != "" {
+			chapter = fmt.Sprintf(" | _%s_", headline)
+		}
 		content = fmt.Sprintf(
 			"%s\n\n %s",
 			dialogFormatted.String(),
 			fmt.Sprintf(
-				"`%s` @ `%s - %s` | Posted by %s",
+				"`%s` @ `%s - %s`%s | Posted by %s",
 				customID.MediaID,
 				dialog[0].StartTimestamp.String(),
 				dialog[len(dialog)-1].EndTimestamp.String(),
+				chapter,
 				username,
 			),
 		)
@@ -612,6 +745,19 @@ func (b *Bot) mediaResponse(
 	}, nil
 }
 
+// chapterHeadline returns the headline of the chapter containing the timestamp. Failing to find it should not
+// prevent the clip being posted so errors are only logged.
+func (b *Bot) chapterHeadline(mediaID string, ts time.Duration) string {
+	ep, err := b.srtStore.GetEpisode(mediaID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			b.logger.Error("Failed to fetch episode", slog.String("media_id", mediaID), slog.String("err", err.Error()))
+		}
+		return ""
+	}
+	return ep.Chapters.HeadlineAt(ts)
+}
+
 func (b *Bot) respondError(s *discordgo.Session, i *discordgo.InteractionCreate, err error, logCtx ...any) {
 	b.logger.Error("Error response was sent: "+err.Error(), logCtx...)
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -641,6 +787,8 @@ func (b *Bot) createMp3(
 			}).
 		Output("pipe:",
 			ffmpeg_go.KwArgs{
+				// only the audio is wanted from video sources or files with embedded artwork
+				"map_0":  "0:a:0",
 				"format": "mp3",
 			},
 		).WithOutput(buff, os.Stderr).Run()
@@ -659,16 +807,6 @@ func (b *Bot) createVideo(
 ) (io.Reader, error) {
 	buff := &bytes.Buffer{}
 
-	imagePath := path.Join(b.mediaPath, fmt.Sprintf("%s.png", strings.TrimSuffix(mediaFileName, path.Ext(mediaFileName))))
-
-	_, err := os.Stat(imagePath)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			imagePath = path.Join(b.mediaPath, "default.png")
-		}
-		return nil, fmt.Errorf("failed to stat image file (%s): %w", imagePath, err)
-	}
-
 	input := []*ffmpeg_go.Stream{
 		ffmpeg_go.Input(
 			path.Join(b.mediaPath, mediaFileName),
@@ -678,17 +816,28 @@ func (b *Bot) createVideo(
 			},
 		),
 	}
-	if imagePath != "" {
+
+	// video sources can just use their own video stream, audio needs an image adding.
+	videoStream := "0:v:0"
+	if !audiometa.IsVideo(mediaFileName) {
+		imagePath := path.Join(b.mediaPath, audiometa.ArtworkPath(mediaFileName))
+		if _, err := os.Stat(imagePath); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to stat image file (%s): %w", imagePath, err)
+			}
+			imagePath = path.Join(b.mediaPath, "default.png")
+		}
 		input = append(input, ffmpeg_go.Input(imagePath))
+		videoStream = "1:v"
 	}
 
-	err = ffmpeg_go.
+	err := ffmpeg_go.
 		Output(
 			input,
 			"pipe:",
 			ffmpeg_go.KwArgs{
-				"map_0":  "0:a",
-				"map_1":  "1:v",
+				"map_0":  "0:a:0",
+				"map_1":  videoStream,
 				"vf":     "scale=220:220",
 				"format": "webm",
 			},
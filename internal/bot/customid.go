@@ -20,17 +20,38 @@ func withMediaType(t MediaType) customIDOpt {
 	}
 }
 
+// withStartLine resets the word trim since it applied to the previous start line.
 func withStartLine(pos int32) customIDOpt {
 	return func(c *CustomID) {
+		if c.StartLine != pos {
+			c.TrimStartWords = 0
+		}
 		c.StartLine = pos
 	}
 }
+
+// withEndLine resets the word trim since it applied to the previous end line.
 func withEndLine(pos int32) customIDOpt {
 	return func(c *CustomID) {
+		if c.EndLine != pos {
+			c.TrimEndWords = 0
+		}
 		c.EndLine = pos
 	}
 }
 
+func withTrimStartWords(num int32) customIDOpt {
+	return func(c *CustomID) {
+		c.TrimStartWords = num
+	}
+}
+
+func withTrimEndWords(num int32) customIDOpt {
+	return func(c *CustomID) {
+		c.TrimEndWords = num
+	}
+}
+
 type CustomID struct {
 	MediaID         string          `json:"e,omitempty"`
 	StartLine       int32           `json:"s,omitempty"`
@@ -38,6 +59,10 @@ type CustomID struct {
 	NumContextLines int             `json:"c,omitempty"`
 	MediaType       MediaType       `json:"m,omitempty"`
 	ContentModifier ContentModifier `json:"t,omitempty"`
+	// TrimStartWords is the number of words removed from the start of the first line.
+	TrimStartWords int32 `json:"ws,omitempty"`
+	// TrimEndWords is the number of words removed from the end of the last line.
+	TrimEndWords int32 `json:"we,omitempty"`
 }
 
 func (c CustomID) String() string {
@@ -63,6 +88,8 @@ func (c CustomID) withOption(options ...customIDOpt) CustomID {
 		NumContextLines: c.NumContextLines,
 		ContentModifier: c.ContentModifier,
 		MediaType:       c.MediaType,
+		TrimStartWords:  c.TrimStartWords,
+		TrimEndWords:    c.TrimEndWords,
 	}
 	for _, v := range options {
 		v(clone)
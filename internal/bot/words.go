@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"strings"
+)
+
+// trimWords removes words from the start of the first line and the end of the last line so clips do not
+// have to start and end on line boundaries. Lines without word timestamps are not trimmed and at least
+// one word is always kept.
+func trimWords(dialog []model.Dialog, trimStart int32, trimEnd int32) []model.Dialog {
+	if len(dialog) == 0 {
+		return dialog
+	}
+	trimmed := make([]model.Dialog, len(dialog))
+	copy(trimmed, dialog)
+	if len(trimmed) == 1 {
+		trimmed[0] = trimLine(trimmed[0], int(trimStart), int(trimEnd))
+		return trimmed
+	}
+	trimmed[0] = trimLine(trimmed[0], int(trimStart), 0)
+	trimmed[len(trimmed)-1] = trimLine(trimmed[len(trimmed)-1], 0, int(trimEnd))
+	return trimmed
+}
+
+func trimLine(d model.Dialog, start int, end int) model.Dialog {
+	if len(d.Words) == 0 {
+		return d
+	}
+	start = max(min(start, len(d.Words)-1), 0)
+	end = max(min(end, len(d.Words)-1-start), 0)
+	if start == 0 && end == 0 {
+		return d
+	}
+
+	// keep the original text if it still matches the words e.g. to preserve punctuation fixed by hand.
+	content := strings.Fields(d.Content)
+	if len(content) == len(d.Words) {
+		d.Content = strings.Join(content[start:len(content)-end], " ")
+	} else {
+		text := []string{}
+		for _, w := range d.Words[start : len(d.Words)-end] {
+			text = append(text, w.Text)
+		}
+		d.Content = strings.Join(text, " ")
+	}
+
+	d.Words = d.Words[start : len(d.Words)-end]
+	if start > 0 {
+		d.StartTimestamp = d.Words[0].Start
+	}
+	if end > 0 {
+		d.EndTimestamp = d.Words[len(d.Words)-1].End
+	}
+	return d
+}
+
+// searchPhrase returns the normalised words of the first quoted phrase in the search terms, if there is one.
+func searchPhrase(terms []searchterms.Term) []string {
+	for _, t := range terms {
+		if t.Field == "content" && t.Op == searchterms.CompOpEq {
+			return normaliseWords(t.Value.Value().(string))
+		}
+	}
+	return nil
+}
+
+// phraseTrim finds the words to trim from the line so that it starts and ends with the phrase. The positions
+// are of the word timestamps rather than the content since that is what trimLine removes, and they may
+// not match if the content was corrected. Zero is returned for both if the phrase cannot be found.
+func phraseTrim(words []model.TranscriptWord, phrase []string) (int32, int32) {
+	if len(phrase) == 0 || len(phrase) > len(words) {
+		return 0, 0
+	}
+	for start := 0; start <= len(words)-len(phrase); start++ {
+		match := true
+		for k, w := range phrase {
+			if normaliseWord(words[start+k].Text) != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return int32(start), int32(len(words) - start - len(phrase))
+		}
+	}
+	return 0, 0
+}
+
+// normaliseWords splits the text into lower case words without punctuation.
+func normaliseWords(text string) []string {
+	words := []string{}
+	for _, w := range strings.Fields(text) {
+		if w = normaliseWord(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func normaliseWord(word string) string {
+	return strings.ToLower(punctuation.ReplaceAllString(word, ""))
+}
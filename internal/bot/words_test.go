@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrimWords(t *testing.T) {
+	words := func(start time.Duration, text ...string) []model.TranscriptWord {
+		out := []model.TranscriptWord{}
+		for k, v := range text {
+			out = append(out, model.TranscriptWord{Text: v, Start: start + time.Second*time.Duration(k), End: start + time.Second*time.Duration(k+1)})
+		}
+		return out
+	}
+	first := model.Dialog{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 3, Content: "One, two three.", Words: words(0, "one", "two", "three")}
+	second := model.Dialog{Pos: 2, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 5, Content: "Four five.", Words: words(time.Second*3, "four", "five")}
+	noWords := model.Dialog{Pos: 3, StartTimestamp: time.Second * 5, EndTimestamp: time.Second * 6, Content: "six"}
+
+	tests := []struct {
+		name      string
+		dialog    []model.Dialog
+		trimStart int32
+		trimEnd   int32
+		want      []model.Dialog
+	}{
+		{
+			name:   "no trim",
+			dialog: []model.Dialog{first, second},
+			want:   []model.Dialog{first, second},
+		}, {
+			name:      "single line",
+			dialog:    []model.Dialog{first},
+			trimStart: 1,
+			trimEnd:   1,
+			want:      []model.Dialog{{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "two", Words: first.Words[1:2]}},
+		}, {
+			name:      "at least one word is kept",
+			dialog:    []model.Dialog{first},
+			trimStart: 5,
+			trimEnd:   5,
+			want:      []model.Dialog{{Pos: 1, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "three.", Words: first.Words[2:]}},
+		}, {
+			name:      "multiple lines",
+			dialog:    []model.Dialog{first, second},
+			trimStart: 2,
+			trimEnd:   1,
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "three.", Words: first.Words[2:]},
+				{Pos: 2, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 4, Content: "Four", Words: second.Words[:1]},
+			},
+		}, {
+			name:      "lines without words are not trimmed",
+			dialog:    []model.Dialog{noWords},
+			trimStart: 1,
+			want:      []model.Dialog{noWords},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualValues(t, tt.want, trimWords(tt.dialog, tt.trimStart, tt.trimEnd))
+		})
+	}
+}
+
+func TestPhraseTrim(t *testing.T) {
+	words := []model.TranscriptWord{}
+	for _, w := range strings.Fields("Well, I said to him: - no way!") {
+		words = append(words, model.TranscriptWord{Text: w})
+	}
+	start, end := phraseTrim(words, searchPhrase(searchterms.MustParse(`"to him no"`)))
+	require.EqualValues(t, 0, start)
+	require.EqualValues(t, 0, end)
+
+	start, end = phraseTrim(words, searchPhrase(searchterms.MustParse(`"said to him"`)))
+	require.EqualValues(t, 2, start)
+	require.EqualValues(t, 3, end)
+
+	// the positions are of the words, not the content which may have been corrected.
+	words = []model.TranscriptWord{{Text: "Well"}, {Text: "I"}, {Text: "sad"}, {Text: "to"}, {Text: "him"}, {Text: "no"}, {Text: "way"}}
+	start, end = phraseTrim(words, searchPhrase(searchterms.MustParse(`"to him no"`)))
+	require.EqualValues(t, 3, start)
+	require.EqualValues(t, 1, end)
+
+	start, end = phraseTrim(words, searchPhrase(searchterms.MustParse(`said`)))
+	require.EqualValues(t, 0, start)
+	require.EqualValues(t, 0, end)
+}
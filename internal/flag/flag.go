@@ -25,6 +25,11 @@ func Int64VarEnv(flagsSet *pflag.FlagSet, s *int64, prefix string, name string,
 	int64FromEnv(s, prefix, name)
 }
 
+func StringSliceVarEnv(flagsSet *pflag.FlagSet, s *[]string, prefix string, name string, value []string, usage string) {
+	flagsSet.StringSliceVar(s, name, value, usage)
+	stringSliceFromEnv(s, prefix, name)
+}
+
 func stringFromEnv(p *string, prefix, name string) {
 	if prefix != "" {
 		prefix = strings.ToUpper(prefix) + "_"
@@ -37,6 +42,15 @@ func stringFromEnv(p *string, prefix, name string) {
 	*p = *valPtr
 }
 
+func stringSliceFromEnv(p *[]string, prefix, name string) {
+	var val string
+	stringFromEnv(&val, prefix, name)
+	if val == "" {
+		return
+	}
+	*p = strings.Split(val, ",")
+}
+
 func boolFromEnv(p *bool, prefix, name string) {
 	if prefix != "" {
 		prefix = "_" + strings.ToUpper(prefix)
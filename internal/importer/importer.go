@@ -2,47 +2,150 @@ package importer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"github.com/fsnotify/fsnotify"
 	"github.com/jmoiron/sqlx"
 	"github.com/warmans/audio-search-bot/internal/audiometa"
 	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/model"
 	"github.com/warmans/audio-search-bot/internal/search"
 	"github.com/warmans/audio-search-bot/internal/store"
+	"github.com/warmans/audio-search-bot/internal/subtitle"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
-const filePollingInterval = time.Second * 10
+const (
+	// failed files are retried with an exponential backoff between these limits.
+	minRetryInterval = time.Minute
+	maxRetryInterval = time.Hour * 24
+)
+
+func retryBackoff(attempts int) time.Duration {
+	backoff := minRetryInterval
+	for k := 1; k < attempts && backoff < maxRetryInterval; k++ {
+		backoff *= 2
+	}
+	return min(backoff, maxRetryInterval)
+}
+
+// Source is the type of file episodes are imported from.
+type Source string
+
+const (
+	// SourceSRT imports SRTs from the media dir, writing the metadata to the metadata dir.
+	SourceSRT Source = "srt"
+	// SourceMetadata imports metadata JSON files from the metadata dir e.g. ones that were edited by hand or
+	// produced by another instance.
+	SourceMetadata Source = "metadata"
+)
 
 type pendingFile struct {
-	srtFilePath string
-	modTime     time.Time
+	// filePath is the SRT or metadata file the episode is imported from.
+	filePath string
+	modTime  time.Time
+	// mediaFilePath is only set for SRTs.
+	mediaFilePath string
+	mediaModTime  time.Time
+}
+
+func (f pendingFile) manifestEntry() (store.ManifestEntry, error) {
+	hashPaths := []string{f.filePath}
+	if f.mediaFilePath != "" {
+		// the words are imported with the SRT so it must be re-imported if only they change.
+		if wordsPath := metadata.WordsPath(f.filePath); fileExists(wordsPath) {
+			hashPaths = append(hashPaths, wordsPath)
+		}
+	}
+	fileHash, err := hashFile(hashPaths...)
+	if err != nil {
+		return store.ManifestEntry{}, err
+	}
+	var mediaHash string
+	if f.mediaFilePath != "" {
+		if mediaHash, err = hashFile(f.mediaFilePath); err != nil {
+			return store.ManifestEntry{}, err
+		}
+	}
+	return store.ManifestEntry{
+		SRTFile:      f.filePath,
+		SRTModTime:   f.modTime,
+		SRTHash:      fileHash,
+		MediaModTime: f.mediaModTime,
+		MediaHash:    mediaHash,
+	}, nil
 }
 
-func (f pendingFile) inferredMediaPath() string {
-	return fmt.Sprintf("%s.mp3", strings.TrimSuffix(f.srtFilePath, path.Ext(f.srtFilePath)))
+// hashFile returns a single hash of the contents of all the given files.
+func hashFile(filePaths ...string) (string, error) {
+	hash := sha256.New()
+	for _, filePath := range filePaths {
+		if err := copyFile(hash, filePath); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func copyFile(w io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for hashing: %w", filePath, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", filePath, err)
+	}
+	return nil
+}
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
 }
 
 func NewIncrementalImporter(
 	srtDir string,
 	metadataDir string,
 	conn *store.Conn,
-	searcher *search.BlugeSearch,
+	searcher search.Index,
 	logger *slog.Logger,
 	useFilePolling bool,
+	filePatterns metadata.FilePatterns,
+	mediaExtensions []string,
+	importWorkers int,
+	source Source,
+	transcriber transcriber.Transcriber,
+	transcriptionWorkers int,
 ) *Incremental {
 	return &Incremental{
-		srtDir:         srtDir,
-		metadataDir:    metadataDir,
-		conn:           conn,
-		searcher:       searcher,
-		logger:         logger,
-		useFilePolling: useFilePolling,
+		srtDir:               srtDir,
+		metadataDir:          metadataDir,
+		conn:                 conn,
+		searcher:             searcher,
+		logger:               logger,
+		useFilePolling:       useFilePolling,
+		filePatterns:         filePatterns,
+		mediaExtensions:      mediaExtensions,
+		importWorkers:        importWorkers,
+		source:               source,
+		transcriber:          transcriber,
+		transcriptionWorkers: transcriptionWorkers,
+		transcriptionQueued:  make(chan struct{}, max(transcriptionWorkers, 1)),
+		status:               newStatusTracker(),
+		pollInterval:         filePollingInterval,
+		quietPeriod:          fileQuietPeriod,
+		reconcileInterval:    reconcileInterval,
 	}
 }
 
@@ -50,18 +153,39 @@ type Incremental struct {
 	srtDir         string
 	metadataDir    string
 	conn           *store.Conn
-	searcher       *search.BlugeSearch
+	searcher       search.Index
 	logger         *slog.Logger
 	useFilePolling bool
-}
+	filePatterns   metadata.FilePatterns
+	// mediaExtensions are in order of preference e.g. [.mp3, .wav]
+	mediaExtensions []string
+	// importWorkers limits how many files are probed and parsed concurrently.
+	importWorkers int
+	source        Source
+	status        *statusTracker
 
-func (i *Incremental) Start(ctx context.Context) error {
+	// transcriber is optional, if set media without an SRT is transcribed.
+	transcriber          transcriber.Transcriber
+	transcriptionWorkers int
+	transcriptionQueued  chan struct{}
 
-	i.logger.Info("Starting initial file sync...")
-	if err := i.importAllNew(ctx); err != nil {
-		return err
+	pollInterval      time.Duration
+	quietPeriod       time.Duration
+	reconcileInterval time.Duration
+}
+
+// Status returns a snapshot of the import progress.
+func (i *Incremental) Status(ctx context.Context) (model.ImportStatus, error) {
+	status := i.status.snapshot()
+	count, err := i.searcher.Count(ctx)
+	if err != nil {
+		return status, fmt.Errorf("failed to count index documents: %w", err)
 	}
+	status.IndexDocumentCount = count
+	return status, nil
+}
 
+func (i *Incremental) Start(ctx context.Context) error {
 	i.logger.Info("Starting incremental file sync...", slog.Bool("polling", i.useFilePolling))
 	if i.useFilePolling {
 		return i.startFilePolling(ctx)
@@ -69,130 +193,106 @@ func (i *Incremental) Start(ctx context.Context) error {
 	return i.startFileWatch(ctx)
 }
 
-func (i *Incremental) startFilePolling(ctx context.Context) error {
-	for {
-		time.Sleep(filePollingInterval)
-		if err := i.importAllNew(ctx); err != nil {
-			return err
-		}
-	}
-}
-
-func (i *Incremental) startFileWatch(ctx context.Context) error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return err
-	}
-	defer watcher.Close()
-
-	// since files are typically added in batches
-	// group up as many pending files as are detected in a 2s period
-	// instead of dispatching an import for each file.
-	ticker := time.NewTicker(time.Second * 2)
-	var pendingFiles []pendingFile
-
-	// Start listening for events.
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case event, ok := <-watcher.Events:
-				if !ok || !strings.HasSuffix(event.Name, ".srt") {
-					return
-				}
-				if event.Has(fsnotify.Create) {
-					stat, err := os.Stat(event.Name)
-					if err != nil {
-						i.logger.Error("failed stat file", slog.String("err", err.Error()))
-						continue
-					}
-
-					pending := pendingFile{srtFilePath: event.Name, modTime: stat.ModTime()}
-
-					mediaExists, err := i.mediaFileExists(pending)
-					if err != nil {
-						i.logger.Error("failed to locate associated media file", slog.String("err", err.Error()), slog.String("srtPath", event.Name))
-						continue
-					}
-					if !mediaExists {
-						i.logger.Info("no media file for SRT, skipping for now...", slog.String("srtPath", event.Name))
-						continue
-					}
-					pendingFiles = append(pendingFiles, pending)
-				}
-			case err, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-				i.logger.Error("watcher error", slog.String("err", err.Error()))
-			case <-ticker.C:
-				if len(pendingFiles) > 0 {
-					if err := i.importNew(ctx, pendingFiles); err != nil {
-						i.logger.Error(
-							"Failed to import pending files",
-							slog.String("err", err.Error()),
-						)
-					}
-					pendingFiles = []pendingFile{}
-				}
-			}
-		}
-	}()
-
-	// Add a path.
-	err = watcher.Add(i.srtDir)
-	if err != nil {
+// initialSync must be run after any watches are established so that no changes are missed.
+func (i *Incremental) initialSync(ctx context.Context) error {
+	i.logger.Info("Starting initial file sync...")
+	if err := i.importAllNew(ctx, true); err != nil {
 		return err
 	}
-
-	<-ctx.Done()
-
-	return nil
+	return i.startTranscription(ctx)
 }
 
-func (i *Incremental) importAllNew(ctx context.Context) error {
+// importAllNew scans the source dir for new or modified files. Media without subtitles is also queued for
+// transcription if queueTranscriptions is true.
+func (i *Incremental) importAllNew(ctx context.Context, queueTranscriptions bool) error {
+	i.status.setPhase(model.ImportPhaseScanning)
+
 	manifest, err := store.NewSRTStore(i.conn.Db).GetManifest()
 	if err != nil {
+		i.status.syncFinished(err)
 		return err
 	}
-
-	dirEntries, err := os.ReadDir(i.srtDir)
+	failures, err := i.getImportFailures()
 	if err != nil {
+		i.status.syncFinished(err)
 		return err
 	}
 
 	toImport := []pendingFile{}
-	for _, v := range dirEntries {
-		if !strings.HasSuffix(v.Name(), ".srt") {
-			continue
+	// problems with individual files (e.g. files that are removed during the scan) are logged and skipped so
+	// they do not prevent the rest of the files being imported.
+	err = filepath.WalkDir(i.sourceDir(), func(filePath string, v fs.DirEntry, err error) error {
+		if err != nil {
+			i.logger.Warn("Failed to read path, skipping...", slog.String("path", filePath), slog.String("err", err.Error()))
+			if v != nil && v.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if v.IsDir() {
+			return nil
+		}
+		if !i.isSourceFile(filePath) {
+			if queueTranscriptions && i.source == SourceSRT && i.isImportable(filePath) {
+				if err := i.queueTranscription(filePath); err != nil {
+					i.logger.Error("failed to queue transcription", slog.String("err", err.Error()), slog.String("path", filePath))
+				}
+			}
+			return nil
+		}
+		if sourceFilePath := i.findSourceFile(filePath); sourceFilePath != filePath {
+			i.logger.Debug("episode has more than one subtitle file, skipping...", slog.String("path", filePath), slog.String("preferred", sourceFilePath))
+			return nil
 		}
 		inf, err := v.Info()
 		if err != nil {
-			return err
+			i.logger.Warn("Failed to stat file, skipping...", slog.String("path", filePath), slog.String("err", err.Error()))
+			return nil
+		}
+		pending, err := i.newPendingFile(filePath, inf.ModTime())
+		if err != nil {
+			i.logger.Warn("Failed to locate associated media file, skipping...", slog.String("path", filePath), slog.String("err", err.Error()))
+			return nil
 		}
-		addToImport := false
-		if oldModTime, ok := manifest[path.Join(i.srtDir, v.Name())]; ok {
-			if inf.ModTime().After(oldModTime) {
-				i.logger.Info("file older than existing",
-					slog.String("path", path.Join(i.srtDir, v.Name())),
-					slog.Time("old", oldModTime),
-					slog.Time("new", inf.ModTime()),
-				)
-				addToImport = true
+		if pending == nil {
+			return nil
+		}
+
+		// failed files are not retried until the backoff has expired unless they are modified.
+		if failure, ok := failures[pending.filePath]; ok && failure.Quarantined(time.Now()) {
+			if !pending.modTime.After(failure.LastAttempt) && !pending.mediaModTime.After(failure.LastAttempt) {
+				i.logger.Debug("file is quarantined, skipping for now...", slog.String("srtPath", pending.filePath), slog.Time("next_attempt", failure.NextAttempt))
+				return nil
 			}
-		} else {
-			addToImport = true
 		}
-		if addToImport {
-			toImport = append(toImport, pendingFile{srtFilePath: path.Join(i.srtDir, v.Name()), modTime: inf.ModTime()})
+
+		// files that have been touched are only imported if their content hash has also changed
+		// but that is decided at import time, since hashing every file on every sync is expensive.
+		if entry, ok := manifest[pending.filePath]; ok {
+			if entry.Unchanged(pending.modTime, pending.mediaModTime) {
+				return nil
+			}
+			i.logger.Info("file modified since last import",
+				slog.String("path", pending.filePath),
+				slog.Time("old", entry.SRTModTime),
+				slog.Time("new", pending.modTime),
+				slog.Time("old_media", entry.MediaModTime),
+				slog.Time("new_media", pending.mediaModTime),
+			)
 		}
+		toImport = append(toImport, *pending)
+		return nil
+	})
+	if err != nil {
+		i.status.syncFinished(err)
+		return err
 	}
 	if len(toImport) == 0 {
+		i.status.syncFinished(nil)
 		return nil
 	}
 
-	i.logger.Info("Importing files...", slog.Int("num_files", len(toImport)), slog.String("dir", i.srtDir))
+	i.logger.Info("Importing files...", slog.Int("num_files", len(toImport)), slog.String("dir", i.sourceDir()))
 	if err := i.importNew(ctx, toImport); err != nil {
 		i.logger.Error(
 			"Failed to import pending files",
@@ -202,42 +302,133 @@ func (i *Incremental) importAllNew(ctx context.Context) error {
 	return nil
 }
 
-func (i *Incremental) importNew(ctx context.Context, pendingFiles []pendingFile) error {
+// preparedFile is the result of the expensive parts of an import (hashing, probing the media and parsing the SRT)
+// which are safe to run concurrently.
+type preparedFile struct {
+	pending       pendingFile
+	manifestEntry store.ManifestEntry
+	// meta is nil if the content was unchanged when the file was prepared.
+	meta *model.Audio
+	err  error
+}
 
-	for k, pending := range pendingFiles {
+func (i *Incremental) importNew(ctx context.Context, pendingFiles []pendingFile) (err error) {
 
-		if err := audiometa.DumpMeta(pending.inferredMediaPath()); err != nil {
-			return fmt.Errorf("failed to dump metadata for file: %s: %w", pending.inferredMediaPath(), err)
-		}
+	i.status.setPhase(model.ImportPhaseImporting)
+	i.status.queue(len(pendingFiles))
+	defer func() {
+		i.status.syncFinished(err)
+	}()
 
-		err := i.conn.WithTx(func(tx *sqlx.Tx) error {
-			meta, err := metadata.CreateMetadataFromSRT(pending.srtFilePath, i.metadataDir)
-			if err != nil {
-				return fmt.Errorf("failed to create metadata: %w", err)
+	manifest, err := store.NewSRTStore(i.conn.Db).GetManifest()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// each file has its own result channel so the results can be written in the original order.
+	results := make([]chan preparedFile, len(pendingFiles))
+	for k := range results {
+		results[k] = make(chan preparedFile, 1)
+	}
+
+	work := make(chan int)
+	go func() {
+		defer close(work)
+		for k := range pendingFiles {
+			select {
+			case <-ctx.Done():
+				return
+			case work <- k:
+			}
+		}
+	}()
+	for w := 0; w < max(i.importWorkers, 1); w++ {
+		go func() {
+			for k := range work {
+				results[k] <- i.prepareFile(pendingFiles[k], manifest)
 			}
-			logger := i.logger.With(slog.String("media_id", meta.ID()), slog.Time("modtime", pending.modTime))
+		}()
+	}
+
+	// all writes happen here so the DB and index only ever have a single writer.
+	for k, pending := range pendingFiles {
+		var prepared preparedFile
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case prepared = <-results[k]:
+		}
+		if prepared.err != nil {
+			i.quarantine(pending, prepared.err)
+			i.status.failed(prepared.err)
+			continue
+		}
+
+		// the metadata file is only written once the import has been committed.
+		var imported *model.Audio
+		err = i.conn.WithTx(func(tx *sqlx.Tx) error {
+			logger := i.logger.With(slog.String("srt_file", pending.filePath), slog.Time("modtime", pending.modTime))
 
 			s := store.NewSRTStore(tx)
-			result, err := s.ManifestAdd(pending.srtFilePath, pending.modTime)
+			result, err := s.ManifestAdd(prepared.manifestEntry)
 			if err != nil {
 				return fmt.Errorf("failed to add to manifest: %w", err)
 			}
+			if err := s.ClearImportFailure(pending.filePath); err != nil {
+				return fmt.Errorf("failed to clear import failure: %w", err)
+			}
 			if result == store.UpsertResultNoop {
 				// nothing to do
-				logger.Info("File already processed, skipped")
+				logger.Info("File content unchanged, skipped")
 				return nil
 			}
+			meta := prepared.meta
+			if meta == nil {
+				// the manifest changed since the file was prepared.
+				if meta, err = i.createMetadata(pending); err != nil {
+					return err
+				}
+			}
+			logger = logger.With(slog.String("media_id", meta.ID()))
+			for _, w := range meta.Warnings {
+				logger.Warn("Subtitle problem was repaired or skipped", slog.Int("line", w.Line), slog.String("warning", w.Message), slog.Bool("skipped", w.Skipped))
+			}
 
 			if err := s.ImportMedia(*meta); err != nil {
 				return err
 			}
 
 			logger.Info("Import to index...", slog.String("result", string(result)), slog.Float64("progress", float64(k)/float64(len(pendingFiles))*100))
-			return i.searcher.Import(ctx, meta, result == store.UpsertResultUpdated)
+			if err := i.searcher.Import(ctx, meta, result == store.UpsertResultUpdated); err != nil {
+				return err
+			}
+			imported = meta
+			return nil
 		})
 		if err != nil {
-			return err
+			if ctx.Err() != nil {
+				return err
+			}
+			i.quarantine(pending, err)
+			i.status.failed(err)
+			continue
+		}
+		if imported != nil && i.source == SourceSRT {
+			if err := metadata.WriteMetadata(i.metadataDir, imported); err != nil {
+				// the import succeeded so the file isn't quarantined, the metadata will be re-written when the
+				// file next changes.
+				i.logger.Error("Failed to write metadata", slog.String("srt_file", pending.filePath), slog.String("err", err.Error()))
+			}
+		}
+		if imported != nil {
+			if skipped := model.SkippedBlocks(imported.Warnings); skipped > 0 {
+				i.status.skipped(pending.filePath, skipped)
+			}
 		}
+		i.status.done()
 		if k%100 == 0 {
 			if err := i.searcher.RefreshIndex(); err != nil {
 				return err
@@ -247,14 +438,149 @@ func (i *Incremental) importNew(ctx context.Context, pendingFiles []pendingFile)
 	return i.searcher.RefreshIndex()
 }
 
-func (i *Incremental) mediaFileExists(pending pendingFile) (bool, error) {
-	_, err := os.Stat(pending.inferredMediaPath())
+// quarantine records the failure so the file is not retried until its backoff has expired.
+func (i *Incremental) quarantine(pending pendingFile, importErr error) {
+	failure, err := store.NewSRTStore(i.conn.Db).RecordImportFailure(pending.filePath, importErr, retryBackoff)
+	if err != nil {
+		i.logger.Error("Failed to record import failure", slog.String("srt_file", pending.filePath), slog.String("err", err.Error()))
+		return
+	}
+	i.logger.Error(
+		"Failed to import file, quarantined",
+		slog.String("srt_file", pending.filePath),
+		slog.String("err", importErr.Error()),
+		slog.Int("attempts", failure.Attempts),
+		slog.Time("next_attempt", failure.NextAttempt),
+	)
+}
+
+func (i *Incremental) getImportFailures() (map[string]store.ImportFailure, error) {
+	failures, err := store.NewSRTStore(i.conn.Db).ListImportFailures()
+	if err != nil {
+		return nil, err
+	}
+	failureMap := make(map[string]store.ImportFailure, len(failures))
+	for _, v := range failures {
+		failureMap[v.SRTFile] = v
+	}
+	return failureMap, nil
+}
+
+// prepareFile hashes the file and, unless the content matches the given manifest, creates the metadata.
+func (i *Incremental) prepareFile(pending pendingFile, manifest map[string]store.ManifestEntry) preparedFile {
+	manifestEntry, err := pending.manifestEntry()
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return false, nil
+		return preparedFile{err: err}
+	}
+	prepared := preparedFile{pending: pending, manifestEntry: manifestEntry}
+	if original, ok := manifest[pending.filePath]; ok && original.SameContent(manifestEntry) {
+		return prepared
+	}
+	prepared.meta, prepared.err = i.createMetadata(pending)
+	return prepared
+}
+
+// createMetadata probes the media file and parses the SRT to create the metadata. The media may have changed so
+// the artwork and metadata must always be re-generated. Metadata files are used as-is. The metadata is not written
+// to the metadata dir until the import has been committed.
+func (i *Incremental) createMetadata(pending pendingFile) (*model.Audio, error) {
+	if i.source == SourceMetadata {
+		return metadata.LoadMetadata(pending.filePath)
+	}
+	relativePath, err := filepath.Rel(i.srtDir, pending.filePath)
+	if err != nil {
+		return nil, err
+	}
+	episodeName, err := i.filePatterns.Parse(relativePath)
+	if err != nil {
+		return nil, err
+	}
+	// media is stored relative to the media path so that it can be resolved by the bot.
+	relativeMediaPath, err := filepath.Rel(i.srtDir, pending.mediaFilePath)
+	if err != nil {
+		return nil, err
+	}
+	mediaMeta, err := audiometa.DumpMeta(pending.mediaFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump metadata for file: %s: %w", pending.mediaFilePath, err)
+	}
+	meta, err := metadata.CreateMetadataFromSRT(pending.filePath, relativeMediaPath, episodeName, mediaMeta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata: %w", err)
+	}
+	return meta, nil
+}
+
+func (i *Incremental) sourceDir() string {
+	if i.source == SourceMetadata {
+		return i.metadataDir
+	}
+	return i.srtDir
+}
+
+// isSourceFile is true for metadata files or any supported subtitle format depending on the source.
+func (i *Incremental) isSourceFile(filePath string) bool {
+	if i.source == SourceMetadata {
+		return path.Ext(filePath) == ".json"
+	}
+	return subtitle.IsSubtitle(filePath)
+}
+
+// wordsExt is the suffix of the word timestamps written alongside transcribed SRTs (see metadata.WordsPath).
+const wordsExt = ".words.json"
+
+func isWordsFile(filePath string) bool {
+	return strings.HasSuffix(filePath, wordsExt)
+}
+
+// findSourceFile returns the source file for the given media, SRT or metadata file or an empty string if
+// there isn't one. If there are several subtitle files for the same media the preferred format is returned.
+func (i *Incremental) findSourceFile(filePath string) string {
+	basePath := strings.TrimSuffix(filePath, path.Ext(filePath))
+	if i.source == SourceSRT && isWordsFile(filePath) {
+		basePath = strings.TrimSuffix(filePath, wordsExt)
+	}
+	if i.source == SourceMetadata {
+		if _, err := os.Stat(basePath + ".json"); err != nil {
+			return ""
 		}
-		return false, fmt.Errorf("failed to stat media file %s: %w", pending.inferredMediaPath(), err)
+		return basePath + ".json"
 	}
+	return subtitle.Find(basePath)
+}
 
-	return true, nil
+// newPendingFile returns nil if the file is not ready to be imported.
+func (i *Incremental) newPendingFile(filePath string, modTime time.Time) (*pendingFile, error) {
+	pending := &pendingFile{filePath: filePath, modTime: modTime}
+	if i.source == SourceMetadata {
+		return pending, nil
+	}
+	mediaPath, mediaStat, err := i.findMediaFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if mediaStat == nil {
+		i.logger.Debug("no media file for SRT, skipping for now...", slog.String("srtPath", filePath))
+		return nil, nil
+	}
+	pending.mediaFilePath = mediaPath
+	pending.mediaModTime = mediaStat.ModTime()
+	return pending, nil
+}
+
+// findMediaFile locates the preferred media file for the given SRT. A nil FileInfo is returned if
+// there is no media file.
+func (i *Incremental) findMediaFile(srtFilePath string) (string, os.FileInfo, error) {
+	for _, ext := range i.mediaExtensions {
+		mediaPath := fmt.Sprintf("%s.%s", strings.TrimSuffix(srtFilePath, path.Ext(srtFilePath)), strings.TrimPrefix(ext, "."))
+		stat, err := os.Stat(mediaPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return "", nil, fmt.Errorf("failed to stat media file %s: %w", mediaPath, err)
+		}
+		return mediaPath, stat, nil
+	}
+	return "", nil, nil
 }
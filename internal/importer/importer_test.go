@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/search"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncremental_findMediaFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"xfm-S01E01.srt", "xfm-S01E01.wav", "xfm-S01E01.mkv", "xfm-S01E02.srt"} {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte{}, 0644))
+	}
+
+	i := &Incremental{mediaExtensions: []string{".mp3", "mkv", ".wav"}}
+
+	mediaPath, stat, err := i.findMediaFile(path.Join(dir, "xfm-S01E01.srt"))
+	require.NoError(t, err)
+	require.NotNil(t, stat)
+	require.EqualValues(t, path.Join(dir, "xfm-S01E01.mkv"), mediaPath)
+
+	_, stat, err = i.findMediaFile(path.Join(dir, "xfm-S01E02.srt"))
+	require.NoError(t, err)
+	require.Nil(t, stat)
+}
+
+func TestIncremental_prepareFile(t *testing.T) {
+	dir := t.TempDir()
+	srtPath := path.Join(dir, "xfm-S01E01.srt")
+	mediaPath := path.Join(dir, "xfm-S01E01.mp3")
+	require.NoError(t, os.WriteFile(srtPath, []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo\n"), 0644))
+	require.NoError(t, os.WriteFile(mediaPath, []byte("not really an mp3"), 0644))
+
+	pending := pendingFile{filePath: srtPath, mediaFilePath: mediaPath}
+	entry, err := pending.manifestEntry()
+	require.NoError(t, err)
+
+	i := &Incremental{srtDir: dir, metadataDir: t.TempDir()}
+
+	// unchanged content should not require the media to be probed.
+	prepared := i.prepareFile(pending, map[string]store.ManifestEntry{srtPath: entry})
+	require.NoError(t, prepared.err)
+	require.Nil(t, prepared.meta)
+	require.EqualValues(t, entry, prepared.manifestEntry)
+
+	// changed content must be probed, which fails since the media is invalid.
+	changed := entry
+	changed.MediaHash = "foo"
+	prepared = i.prepareFile(pending, map[string]store.ManifestEntry{srtPath: changed})
+	require.Error(t, prepared.err)
+}
+
+func TestPendingFile_manifestEntry_words(t *testing.T) {
+	dir := t.TempDir()
+	srtPath := path.Join(dir, "xfm-S01E01.srt")
+	mediaPath := path.Join(dir, "xfm-S01E01.mp3")
+	require.NoError(t, os.WriteFile(srtPath, []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo\n"), 0644))
+	require.NoError(t, os.WriteFile(mediaPath, []byte("not really an mp3"), 0644))
+
+	pending := pendingFile{filePath: srtPath, mediaFilePath: mediaPath}
+	withoutWords, err := pending.manifestEntry()
+	require.NoError(t, err)
+
+	// a changed words file must cause the SRT to be re-imported.
+	require.NoError(t, os.WriteFile(path.Join(dir, "xfm-S01E01.words.json"), []byte(`{"words":[]}`), 0644))
+	withWords, err := pending.manifestEntry()
+	require.NoError(t, err)
+	require.False(t, withoutWords.SameContent(withWords))
+
+	i := &Incremental{source: SourceSRT}
+	require.True(t, i.isImportable(path.Join(dir, "xfm-S01E01.words.json")))
+	require.EqualValues(t, srtPath, i.findSourceFile(path.Join(dir, "xfm-S01E01.words.json")))
+}
+
+// recordingIndex records the order of imports and fails to import the given episodes.
+type recordingIndex struct {
+	search.Index
+	lock     sync.Mutex
+	imported []string
+	fail     map[string]bool
+}
+
+func (r *recordingIndex) Import(ctx context.Context, meta *model.Audio, deleteFirst bool) error {
+	r.lock.Lock()
+	r.imported = append(r.imported, meta.ID())
+	r.lock.Unlock()
+	if r.fail[meta.ID()] {
+		return errors.New("index failed")
+	}
+	return r.Index.Import(ctx, meta, deleteFirst)
+}
+
+func TestIncremental_importNew(t *testing.T) {
+	i := newTestImporter(t, t.TempDir(), false, SourceMetadata)
+	i.importWorkers = 4
+	index := &recordingIndex{Index: search.NewMemorySearch(), fail: map[string]bool{"xfm-S01E04": true}}
+	i.searcher = index
+
+	pending := []pendingFile{}
+	for episode := 1; episode <= 6; episode++ {
+		filePath := path.Join(i.metadataDir, fmt.Sprintf("xfm-S01E%02d.json", episode))
+		if episode == 2 {
+			// fails when the file is prepared.
+			require.NoError(t, os.WriteFile(filePath, []byte("{not json"), 0644))
+		} else {
+			writeJSON(t, filePath, model.Audio{
+				MediaFile:   fmt.Sprintf("xfm-S01E%02d.mp3", episode),
+				Publication: "xfm",
+				Series:      1,
+				Episode:     int32(episode),
+				Dialog:      []model.Dialog{{Pos: 1, Content: "foo"}},
+			})
+		}
+		pending = append(pending, pendingFile{filePath: filePath, modTime: time.Now()})
+	}
+	require.NoError(t, i.importNew(context.Background(), pending))
+
+	// files are written in the order they were given regardless of which worker prepared them first, and a
+	// failure does not prevent the following files being imported.
+	require.EqualValues(t, []string{"xfm-S01E01", "xfm-S01E03", "xfm-S01E04", "xfm-S01E05", "xfm-S01E06"}, index.imported)
+
+	status := i.status.snapshot()
+	require.EqualValues(t, 4, status.Done)
+	require.EqualValues(t, 2, status.Failed)
+
+	failures, err := i.getImportFailures()
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	require.Contains(t, failures, pending[1].filePath)
+	require.Contains(t, failures, pending[3].filePath)
+
+	// the failed index write is rolled back.
+	s := store.NewSRTStore(i.conn.Db)
+	_, err = s.GetEpisode("xfm-S01E04")
+	require.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetEpisode("xfm-S01E06")
+	require.NoError(t, err)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	require.EqualValues(t, time.Minute, retryBackoff(1))
+	require.EqualValues(t, time.Minute*2, retryBackoff(2))
+	require.EqualValues(t, time.Minute*8, retryBackoff(4))
+	require.EqualValues(t, maxRetryInterval, retryBackoff(100))
+}
+
+func TestStatusTracker(t *testing.T) {
+	tracker := newStatusTracker()
+	require.EqualValues(t, model.ImportPhaseStarting, tracker.snapshot().Phase)
+
+	tracker.setPhase(model.ImportPhaseImporting)
+	tracker.queue(3)
+	tracker.done()
+	tracker.failed(errors.New("bad file"))
+
+	status := tracker.snapshot()
+	require.EqualValues(t, model.ImportPhaseImporting, status.Phase)
+	require.EqualValues(t, 1, status.Queued)
+	require.EqualValues(t, 1, status.Done)
+	require.EqualValues(t, 1, status.Failed)
+	require.EqualValues(t, "bad file", status.LastError)
+
+	tracker.skipped("xfm-S01E01.srt", 2)
+	tracker.skipped("xfm-S01E02.srt", 1)
+	status = tracker.snapshot()
+	require.EqualValues(t, 3, status.SkippedBlocks)
+	require.EqualValues(t, "xfm-S01E02.srt", status.LastSkippedFile)
+
+	tracker.syncFinished(nil)
+	status = tracker.snapshot()
+	require.EqualValues(t, model.ImportPhaseIdle, status.Phase)
+	require.EqualValues(t, 0, status.Queued)
+	require.False(t, status.LastSync.IsZero())
+}
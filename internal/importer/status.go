@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type StatusProvider interface {
+	Status(ctx context.Context) (model.ImportStatus, error)
+}
+
+type statusTracker struct {
+	lock   sync.Mutex
+	status model.ImportStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{status: model.ImportStatus{Phase: model.ImportPhaseStarting}}
+}
+
+func (s *statusTracker) snapshot() model.ImportStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.status
+}
+
+func (s *statusTracker) setPhase(phase model.ImportPhase) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Phase = phase
+}
+
+func (s *statusTracker) queue(num int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Queued += num
+}
+
+func (s *statusTracker) done() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Queued = max(s.status.Queued-1, 0)
+	s.status.Done++
+}
+
+func (s *statusTracker) failed(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Queued = max(s.status.Queued-1, 0)
+	s.status.Failed++
+	s.setError(err)
+}
+
+// skipped records malformed blocks that were left out of an imported file.
+func (s *statusTracker) skipped(filePath string, blocks int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.SkippedBlocks += blocks
+	s.status.LastSkippedFile = filePath
+}
+
+// syncFinished clears the queue since any remaining files were abandoned if the sync failed.
+func (s *statusTracker) syncFinished(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Phase = model.ImportPhaseIdle
+	s.status.Queued = 0
+	if err != nil {
+		s.setError(err)
+		return
+	}
+	s.status.LastSync = time.Now()
+}
+
+func (s *statusTracker) setError(err error) {
+	s.status.LastError = err.Error()
+	s.status.LastErrorTime = time.Now()
+}
+
+// NewStatusHandler exposes the status as JSON.
+func NewStatusHandler(provider StatusProvider, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, err := provider.Status(r.Context())
+		if err != nil {
+			logger.Error("failed to get import status", slog.String("err", err.Error()))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			logger.Error("failed to encode import status", slog.String("err", err.Error()))
+		}
+	})
+}
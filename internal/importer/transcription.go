@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"github.com/warmans/audio-search-bot/internal/subtitle"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// failed transcriptions are returned to the queue until they have been attempted this many times. Each retry
+	// waits for the same backoff as quarantined imports.
+	maxTranscriptionAttempts = 3
+	// workers check for new jobs at this interval in case they were not woken when a job was queued.
+	transcriptionPollInterval = time.Minute
+)
+
+// startTranscription starts the workers that process queued transcription jobs until the context is cancelled.
+func (i *Incremental) startTranscription(ctx context.Context) error {
+	if i.transcriber == nil {
+		return nil
+	}
+	if err := store.NewSRTStore(i.conn.Db).ResetRunningTranscriptionJobs(); err != nil {
+		return fmt.Errorf("failed to reset interrupted transcription jobs: %w", err)
+	}
+	for w := 0; w < max(i.transcriptionWorkers, 1); w++ {
+		go i.transcriptionWorker(ctx)
+	}
+	return nil
+}
+
+func (i *Incremental) transcriptionWorker(ctx context.Context) {
+	ticker := time.NewTicker(transcriptionPollInterval)
+	defer ticker.Stop()
+	for {
+		job, err := store.NewSRTStore(i.conn.Db).ClaimTranscriptionJob()
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				i.logger.Error("Failed to claim transcription job", slog.String("err", err.Error()))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-i.transcriptionQueued:
+			case <-ticker.C:
+			}
+			continue
+		}
+
+		logger := i.logger.With(slog.String("media_file", job.MediaFile), slog.Int("attempt", job.Attempts))
+		logger.Info("Transcribing media...")
+
+		jobErr := i.transcribe(ctx, job.MediaFile)
+		if jobErr != nil {
+			if ctx.Err() != nil {
+				// the job will be reset on the next start.
+				return
+			}
+			logger.Error("Transcription failed", slog.String("err", jobErr.Error()))
+		} else {
+			logger.Info("Transcription completed")
+		}
+		if err := store.NewSRTStore(i.conn.Db).CompleteTranscriptionJob(job.MediaFile, jobErr, job.Attempts < maxTranscriptionAttempts, retryBackoff(job.Attempts)); err != nil {
+			logger.Error("Failed to complete transcription job", slog.String("err", err.Error()))
+		}
+	}
+}
+
+func (i *Incremental) transcribe(ctx context.Context, mediaPath string) error {
+	_, err := transcriber.WriteSRT(ctx, i.transcriber, mediaPath, srtPathForMedia(mediaPath), transcriber.DefaultSegmentOptions())
+	return err
+}
+
+// queueTranscription queues the media file for transcription if it is the preferred media for an episode that
+// has no subtitles.
+func (i *Incremental) queueTranscription(mediaPath string) error {
+	if i.transcriber == nil {
+		return nil
+	}
+	srtPath := srtPathForMedia(mediaPath)
+	if subtitle.Find(strings.TrimSuffix(mediaPath, path.Ext(mediaPath))) != "" {
+		return nil
+	}
+	preferredMediaPath, _, err := i.findMediaFile(srtPath)
+	if err != nil {
+		return err
+	}
+	if preferredMediaPath != mediaPath {
+		return nil
+	}
+	created, err := store.NewSRTStore(i.conn.Db).AddTranscriptionJob(mediaPath)
+	if err != nil {
+		return err
+	}
+	if created {
+		i.logger.Info("Queued media for transcription", slog.String("media_file", mediaPath))
+		select {
+		case i.transcriptionQueued <- struct{}{}:
+		default:
+			// workers are already busy or have a pending wake up.
+		}
+	}
+	return nil
+}
+
+func srtPathForMedia(mediaPath string) string {
+	return fmt.Sprintf("%s.srt", strings.TrimSuffix(mediaPath, path.Ext(mediaPath)))
+}
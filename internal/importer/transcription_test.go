@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"github.com/warmans/audio-search-bot/internal/transcriber"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTranscriber records which files were transcribed.
+type fakeTranscriber struct {
+	*transcriber.Fake
+	lock        sync.Mutex
+	transcribed []string
+}
+
+func (f *fakeTranscriber) Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error) {
+	f.lock.Lock()
+	f.transcribed = append(f.transcribed, mediaPath)
+	f.lock.Unlock()
+	return f.Fake.Transcribe(ctx, mediaPath)
+}
+
+func TestIncremental_transcription(t *testing.T) {
+	mediaDir := t.TempDir()
+
+	// existing media is queued by the initial sync
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E01.mp3"), []byte("not really an mp3"), 0644))
+	// only the preferred media is transcribed
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E01.wav"), []byte("not really a wav"), 0644))
+	// media with an SRT is not transcribed
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E02.mp3"), []byte("not really an mp3"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E02.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nbar\n"), 0644))
+
+	fake := &fakeTranscriber{Fake: transcriber.NewFake()}
+	i := newTestImporter(t, mediaDir, false, SourceSRT)
+	i.transcriber = fake
+	startImporter(t, i)
+
+	// new media is queued by the watcher
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E03.mp3"), []byte("not really an mp3"), 0644))
+
+	require.Eventually(t, func() bool {
+		jobs, err := store.NewSRTStore(i.conn.Db).ListTranscriptionJobs(store.TranscriptionDone)
+		require.NoError(t, err)
+		return len(jobs) == 2
+	}, time.Second*5, time.Millisecond*10)
+
+	fake.lock.Lock()
+	require.ElementsMatch(t, []string{path.Join(mediaDir, "xfm-S01E01.mp3"), path.Join(mediaDir, "xfm-S01E03.mp3")}, fake.transcribed)
+	fake.lock.Unlock()
+
+	// the SRTs are written next to the media and imported (one existing + two transcribed)
+	require.FileExists(t, path.Join(mediaDir, "xfm-S01E03.srt"))
+	require.Eventually(t, func() bool { return attempted(i) == 3 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestIncremental_importAllNew_queueTranscriptions(t *testing.T) {
+	mediaDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E01.mp3"), []byte("not really an mp3"), 0644))
+
+	i := newTestImporter(t, mediaDir, true, SourceSRT)
+	i.transcriber = &fakeTranscriber{Fake: transcriber.NewFake()}
+
+	pending := func() int {
+		jobs, err := store.NewSRTStore(i.conn.Db).ListTranscriptionJobs(store.TranscriptionPending)
+		require.NoError(t, err)
+		return len(jobs)
+	}
+
+	// polling does not check every media file for subtitles, only the initial and reconcile scans do.
+	require.NoError(t, i.importAllNew(context.Background(), false))
+	require.EqualValues(t, 0, pending())
+
+	require.NoError(t, i.importAllNew(context.Background(), true))
+	require.EqualValues(t, 1, pending())
+}
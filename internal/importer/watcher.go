@@ -0,0 +1,265 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	filePollingInterval = time.Second * 10
+	// files are only imported once they have had no events and their size has not changed for this period.
+	fileQuietPeriod = time.Second * 2
+	// events may be missed (e.g. if the kernel queue overflows) so a full scan is also periodically done.
+	reconcileInterval = time.Minute * 15
+)
+
+func (i *Incremental) startFilePolling(ctx context.Context) error {
+	if err := i.initialSync(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(i.pollInterval)
+	defer ticker.Stop()
+
+	// checking every media file for subtitles is too expensive to do on every poll so new media is only queued
+	// for transcription periodically.
+	reconcileTicker := time.NewTicker(i.reconcileInterval)
+	defer reconcileTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			i.rescan(ctx, false)
+		case <-reconcileTicker.C:
+			i.rescan(ctx, true)
+		}
+	}
+}
+
+func (i *Incremental) startFileWatch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// existing files are handled by the initial sync.
+	if err := i.watchRecursive(watcher, i.sourceDir(), nil); err != nil {
+		return err
+	}
+	if err := i.initialSync(ctx); err != nil {
+		return err
+	}
+
+	changes := newDebouncer(i.quietPeriod)
+
+	checkTicker := time.NewTicker(i.quietPeriod / 2)
+	defer checkTicker.Stop()
+
+	reconcileTicker := time.NewTicker(i.reconcileInterval)
+	defer reconcileTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("watcher was closed")
+			}
+			i.handleEvent(watcher, changes, event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("watcher was closed")
+			}
+			i.logger.Error("watcher error", slog.String("err", err.Error()))
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// some events were lost so the only way to be sure nothing was missed is a full scan.
+				i.rescan(ctx, true)
+			}
+		case <-checkTicker.C:
+			if changed := changes.ready(time.Now()); len(changed) > 0 {
+				i.importChanged(ctx, changed)
+			}
+		case <-reconcileTicker.C:
+			i.rescan(ctx, true)
+		}
+	}
+}
+
+// rescan imports any files that were missed by the watcher. Failures are logged rather than returned so that
+// a single bad file or a transient error does not stop the watcher.
+func (i *Incremental) rescan(ctx context.Context, queueTranscriptions bool) {
+	if err := i.importAllNew(ctx, queueTranscriptions); err != nil && ctx.Err() == nil {
+		i.logger.Error("Failed to import all new files", slog.String("err", err.Error()))
+	}
+}
+
+func (i *Incremental) handleEvent(watcher *fsnotify.Watcher, changes *debouncer, event fsnotify.Event) {
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		// a rename event has the old name, the new name will get a Create event.
+		changes.forget(event.Name)
+		return
+	}
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+	if event.Has(fsnotify.Create) {
+		if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() {
+			// files may already exist if the directory was moved in or created before the watch was added.
+			if err := i.watchRecursive(watcher, event.Name, changes); err != nil {
+				i.logger.Error("failed to watch new directory", slog.String("err", err.Error()), slog.String("dir", event.Name))
+			}
+			return
+		}
+	}
+	if i.isImportable(event.Name) {
+		changes.touch(event.Name, time.Now())
+	}
+}
+
+// watchRecursive adds a watch for the given dir and all sub-directories since fsnotify
+// does not support recursive watches. Any existing files are added to the changes if given.
+func (i *Incremental) watchRecursive(watcher *fsnotify.Watcher, dir string, changes *debouncer) error {
+	return filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if changes != nil && i.isImportable(filePath) {
+				changes.touch(filePath, time.Now())
+			}
+			return nil
+		}
+		if err := watcher.Add(filePath); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", filePath, err)
+		}
+		return nil
+	})
+}
+
+// isImportable is true for subtitles and media files since either may be the last file of the pair to be written,
+// and for word timestamps since they are imported with the subtitles. Only metadata files are imported from the
+// metadata dir.
+func (i *Incremental) isImportable(filePath string) bool {
+	if i.isSourceFile(filePath) {
+		return true
+	}
+	ext := path.Ext(filePath)
+	if i.source == SourceMetadata {
+		return false
+	}
+	if isWordsFile(filePath) {
+		return true
+	}
+	return slices.ContainsFunc(i.mediaExtensions, func(mediaExt string) bool {
+		return strings.TrimPrefix(mediaExt, ".") == strings.TrimPrefix(ext, ".")
+	})
+}
+
+// importChanged imports the SRTs or metadata files associated with the given changed files.
+func (i *Incremental) importChanged(ctx context.Context, changed []string) {
+	toImport := []pendingFile{}
+	seen := map[string]struct{}{}
+	for _, filePath := range changed {
+		sourceFilePath := i.findSourceFile(filePath)
+		if sourceFilePath == "" {
+			i.logger.Debug("no SRT for changed file, skipping for now...", slog.String("path", filePath))
+			if i.source == SourceSRT {
+				if err := i.queueTranscription(filePath); err != nil {
+					i.logger.Error("failed to queue transcription", slog.String("err", err.Error()), slog.String("path", filePath))
+				}
+			}
+			continue
+		}
+		if _, ok := seen[sourceFilePath]; ok {
+			continue
+		}
+		seen[sourceFilePath] = struct{}{}
+
+		stat, err := os.Stat(sourceFilePath)
+		if err != nil {
+			i.logger.Error("failed stat file", slog.String("err", err.Error()))
+			continue
+		}
+		pending, err := i.newPendingFile(sourceFilePath, stat.ModTime())
+		if err != nil {
+			i.logger.Error("failed to locate associated media file", slog.String("err", err.Error()), slog.String("path", sourceFilePath))
+			continue
+		}
+		if pending == nil {
+			continue
+		}
+		toImport = append(toImport, *pending)
+	}
+	if len(toImport) == 0 {
+		return
+	}
+	if err := i.importNew(ctx, toImport); err != nil {
+		i.logger.Error("Failed to import pending files", slog.String("err", err.Error()))
+	}
+}
+
+// debouncer collects changed files until they have stopped changing. Files that are still being written
+// may not generate events (e.g. on network mounts) so the size and modification time must also be stable.
+type debouncer struct {
+	quietPeriod time.Duration
+	pending     map[string]*pendingChange
+}
+
+type pendingChange struct {
+	lastChange time.Time
+	size       int64
+	modTime    time.Time
+}
+
+func newDebouncer(quietPeriod time.Duration) *debouncer {
+	return &debouncer{quietPeriod: quietPeriod, pending: map[string]*pendingChange{}}
+}
+
+func (d *debouncer) touch(filePath string, now time.Time) {
+	if change, ok := d.pending[filePath]; ok {
+		change.lastChange = now
+		return
+	}
+	d.pending[filePath] = &pendingChange{lastChange: now, size: -1}
+}
+
+func (d *debouncer) forget(filePath string) {
+	delete(d.pending, filePath)
+}
+
+// ready returns the files that have finished changing, sorted by path. Files that no longer exist are dropped.
+func (d *debouncer) ready(now time.Time) []string {
+	ready := []string{}
+	for filePath, change := range d.pending {
+		if now.Sub(change.lastChange) < d.quietPeriod {
+			continue
+		}
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			delete(d.pending, filePath)
+			continue
+		}
+		if stat.Size() != change.size || !stat.ModTime().Equal(change.modTime) {
+			change.size, change.modTime, change.lastChange = stat.Size(), stat.ModTime(), now
+			continue
+		}
+		ready = append(ready, filePath)
+		delete(d.pending, filePath)
+	}
+	sort.Strings(ready)
+	return ready
+}
@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/audiometa"
+	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/search"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"log/slog"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func newTestImporter(t *testing.T, mediaDir string, useFilePolling bool, source Source) *Incremental {
+	conn, err := store.NewConn(&store.Config{DSN: path.Join(t.TempDir(), "dialog.sqlite3")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+	require.NoError(t, conn.Migrate())
+
+	i := NewIncrementalImporter(
+		mediaDir,
+		t.TempDir(),
+		conn,
+		search.NewMemorySearch(),
+		slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		useFilePolling,
+		metadata.DefaultFilePatterns(),
+		audiometa.DefaultMediaExtensions,
+		1,
+		source,
+		nil,
+		1,
+	)
+	i.pollInterval = time.Millisecond * 50
+	i.quietPeriod = time.Millisecond * 50
+	return i
+}
+
+// startImporter runs the importer until the test ends and waits for the initial sync to complete.
+func startImporter(t *testing.T, i *Incremental) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- i.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-stopped:
+			require.NoError(t, err)
+		case <-time.After(time.Second * 5):
+			t.Fatal("importer did not stop after context was cancelled")
+		}
+	})
+	require.Eventually(t, func() bool {
+		return i.status.snapshot().Phase == model.ImportPhaseIdle
+	}, time.Second*5, time.Millisecond*10)
+}
+
+// attempted is the number of files that were imported or quarantined. The media files are not valid so
+// whether they succeed depends on ffprobe being available, but either way they were picked up by the importer.
+func attempted(i *Incremental) int {
+	status := i.status.snapshot()
+	return status.Done + status.Failed
+}
+
+func TestIncremental_startFileWatch(t *testing.T) {
+	mediaDir := t.TempDir()
+	i := newTestImporter(t, mediaDir, false, SourceSRT)
+	startImporter(t, i)
+
+	// unrelated files must not stop the watcher
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "notes.txt"), []byte("foo"), 0644))
+
+	// files in new sub-directories are watched
+	require.NoError(t, os.MkdirAll(path.Join(mediaDir, "xfm", "season 1"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm", "season 1", "xfm-S01E01.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm", "season 1", "xfm-S01E01.mp3"), []byte("not really an mp3"), 0644))
+
+	require.Eventually(t, func() bool { return attempted(i) == 1 }, time.Second*5, time.Millisecond*10)
+
+	// media files moved in after the SRT trigger an import
+	tmpMedia := path.Join(t.TempDir(), "xfm-S01E02.mp3")
+	require.NoError(t, os.WriteFile(tmpMedia, []byte("not really an mp3"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E02.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nbar\n"), 0644))
+	require.NoError(t, os.Rename(tmpMedia, path.Join(mediaDir, "xfm-S01E02.mp3")))
+
+	require.Eventually(t, func() bool { return attempted(i) == 2 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestIncremental_startFileWatch_rescanFailure(t *testing.T) {
+	mediaDir := t.TempDir()
+	i := newTestImporter(t, mediaDir, false, SourceSRT)
+	i.reconcileInterval = time.Millisecond * 20
+	startImporter(t, i)
+
+	// the manifest cannot be read so every rescan fails.
+	_, err := i.conn.Db.Exec(`ALTER TABLE manifest RENAME TO manifest_broken`)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 100)
+	_, err = i.conn.Db.Exec(`ALTER TABLE manifest_broken RENAME TO manifest`)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E01.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E01.mp3"), []byte("not really an mp3"), 0644))
+
+	require.Eventually(t, func() bool { return attempted(i) == 1 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestIncremental_startFilePolling(t *testing.T) {
+	mediaDir := t.TempDir()
+	i := newTestImporter(t, mediaDir, true, SourceSRT)
+	startImporter(t, i)
+
+	require.NoError(t, os.MkdirAll(path.Join(mediaDir, "xfm"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm", "xfm-S01E01.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm", "xfm-S01E01.mp3"), []byte("not really an mp3"), 0644))
+
+	require.Eventually(t, func() bool { return attempted(i) == 1 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestIncremental_startFilePolling_badFiles(t *testing.T) {
+	mediaDir := t.TempDir()
+	i := newTestImporter(t, mediaDir, true, SourceSRT)
+	startImporter(t, i)
+
+	// media that cannot be stat'd (e.g. because it was removed or replaced during the scan) must not prevent
+	// other files being imported or stop the importer.
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E01.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo\n"), 0644))
+	require.NoError(t, os.Symlink(path.Join(mediaDir, "xfm-S01E01.mp3"), path.Join(mediaDir, "xfm-S01E01.mp3")))
+
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E02.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nbar\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E02.mp3"), []byte("not really an mp3"), 0644))
+
+	require.Eventually(t, func() bool { return attempted(i) == 1 }, time.Second*5, time.Millisecond*10)
+
+	// polling continues once the bad file has been removed.
+	require.NoError(t, os.Remove(path.Join(mediaDir, "xfm-S01E01.mp3")))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E03.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\nbaz\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(mediaDir, "xfm-S01E03.mp3"), []byte("not really an mp3"), 0644))
+
+	require.Eventually(t, func() bool { return attempted(i) == 2 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestIncremental_importMetadata(t *testing.T) {
+	i := newTestImporter(t, t.TempDir(), false, SourceMetadata)
+
+	audio := model.Audio{
+		MediaFile:   "xfm/xfm-S01E01.mp3",
+		Publication: "xfm",
+		Series:      1,
+		Episode:     1,
+		Dialog:      []model.Dialog{{Pos: 1, Content: "one two"}},
+	}
+	writeJSON(t, path.Join(i.metadataDir, "xfm-S01E01.json"), audio)
+	startImporter(t, i)
+
+	doc, err := i.searcher.Get(context.Background(), "xfm-S01E01-1")
+	require.NoError(t, err)
+	require.EqualValues(t, "one two", doc.Content)
+
+	// hand edited transcripts are re-imported
+	audio.Dialog[0].Content = "one too"
+	writeJSON(t, path.Join(i.metadataDir, "xfm-S01E01.json"), audio)
+
+	require.Eventually(t, func() bool {
+		doc, err := i.searcher.Get(context.Background(), "xfm-S01E01-1")
+		return err == nil && doc.Content == "one too"
+	}, time.Second*5, time.Millisecond*10)
+
+	ep, err := store.NewSRTStore(i.conn.Db).GetEpisode("xfm-S01E01")
+	require.NoError(t, err)
+	require.EqualValues(t, "xfm/xfm-S01E01.mp3", ep.MediaFileName)
+}
+
+func writeJSON(t *testing.T, filePath string, v any) {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filePath, data, 0644))
+}
+
+func TestDebouncer(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "xfm-S01E01.mp3")
+	require.NoError(t, os.WriteFile(filePath, []byte("foo"), 0644))
+
+	now := time.Now()
+	changes := newDebouncer(time.Second)
+	changes.touch(filePath, now)
+
+	// too soon
+	require.Empty(t, changes.ready(now.Add(time.Millisecond*500)))
+
+	// the size must also be stable between checks
+	require.Empty(t, changes.ready(now.Add(time.Second)))
+	require.NoError(t, os.WriteFile(filePath, []byte("foo bar"), 0644))
+	require.Empty(t, changes.ready(now.Add(time.Second*2)))
+
+	require.EqualValues(t, []string{filePath}, changes.ready(now.Add(time.Second*3)))
+	require.Empty(t, changes.ready(now.Add(time.Second*4)))
+
+	// removed files are dropped
+	changes.touch(filePath, now)
+	require.NoError(t, os.Remove(filePath))
+	require.Empty(t, changes.ready(now.Add(time.Second)))
+	require.Empty(t, changes.pending)
+}
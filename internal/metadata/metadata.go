@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"path"
+)
+
+// LoadMetadata reads metadata created by CreateMetadataFromSRT or produced externally.
+func LoadMetadata(metaPath string) (*model.Audio, error) {
+	f, err := os.Open(metaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file %s: %w", metaPath, err)
+	}
+	defer f.Close()
+
+	meta := &model.Audio{}
+	if err := json.NewDecoder(f).Decode(meta); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata file %s: %w", metaPath, err)
+	}
+	if meta.Publication == "" {
+		return nil, fmt.Errorf("metadata file %s has no publication", metaPath)
+	}
+	if meta.MediaFile == "" {
+		return nil, fmt.Errorf("metadata file %s has no media file", metaPath)
+	}
+	return meta, nil
+}
+
+// WriteMetadata stores the metadata in the given dir as <media_id>.json.
+func WriteMetadata(metadataDir string, meta *model.Audio) error {
+	if err := writeMetadata(path.Join(metadataDir, fmt.Sprintf("%s.json", meta.ID())), meta); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return nil
+}
+
+// writeMetadata replaces the file atomically since the metadata dir may be watched by other instances.
+func writeMetadata(metaPath string, e *model.Audio) error {
+
+	file, err := os.CreateTemp(path.Dir(metaPath), path.Base(metaPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", metaPath, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(e); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), metaPath)
+}
@@ -0,0 +1,39 @@
+package metadata
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestLoadMetadata(t *testing.T) {
+	dir := t.TempDir()
+
+	audio := &model.Audio{
+		SRTFile:     "xfm-S01E01.srt",
+		MediaFile:   "xfm/xfm-S01E01.mp3",
+		Publication: "xfm",
+		Series:      1,
+		Episode:     1,
+		Duration:    time.Minute,
+		Dialog:      []model.Dialog{{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"}},
+	}
+	metaPath := path.Join(dir, "xfm-S01E01.json")
+	require.NoError(t, writeMetadata(metaPath, audio))
+
+	loaded, err := LoadMetadata(metaPath)
+	require.NoError(t, err)
+	require.EqualValues(t, audio, loaded)
+
+	// no temp files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(metaPath, []byte(`{"media_file": "foo.mp3"}`), 0644))
+	_, err = LoadMetadata(metaPath)
+	require.Error(t, err)
+}
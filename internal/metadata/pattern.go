@@ -0,0 +1,186 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// presetPatterns can be referenced by name in a FilePattern instead of giving a full regex.
+var presetPatterns = map[string]*regexp.Regexp{
+	// e.g. xfm-S01E02
+	"default": regexp.MustCompile(`(?P<publication>[a-zA-Z0-9]+)-S(?P<series>\d+)E(?P<episode>\d+)`),
+	// e.g. Some Show S1E02 or Some Show S01 E02
+	"short": regexp.MustCompile(`^.*[sS](?P<series>\d+)(\s+)?[eE](?P<episode>\d+).*$`),
+	// e.g. Some Show Season 1 Episode 2
+	"long": regexp.MustCompile(`^.*[sS](eason|eries) (?P<series>\d+) [eE]pisode (?P<episode>\d+).*$`),
+	// e.g. Some Show S01.E02
+	"split": regexp.MustCompile(`^.*[sS](?P<series>\d+)\.[eE](?P<episode>\d+).*$`),
+	// e.g. Some Show 1x02
+	"x": regexp.MustCompile(`^\D*(?P<series>\d+)[xX](?P<episode>\d+)\D*$`),
+	// e.g. Some Show 2024-01-30
+	"date": regexp.MustCompile(`(?P<date>\d{4}[-_.]?\d{2}[-_.]?\d{2})`),
+}
+
+// FilePattern describes how episode details are extracted from file names. The pattern may use the
+// named groups publication, series, episode and date.
+type FilePattern struct {
+	// Dir limits the pattern to files within the given directory (relative to the media path).
+	Dir string `json:"dir,omitempty"`
+	// Publication is used if the pattern has no publication group.
+	Publication string `json:"publication,omitempty"`
+	// PublicationFromDir uses the top level directory (relative to the media path) as the publication
+	// if the pattern has no publication group and no Publication is configured e.g. media/<publication>/<season>/...
+	PublicationFromDir bool `json:"publication_from_dir,omitempty"`
+	// Pattern is either a regex or the name of a preset e.g. "short".
+	Pattern string `json:"pattern"`
+
+	regex *regexp.Regexp
+}
+
+func (f *FilePattern) compile() error {
+	if preset, ok := presetPatterns[f.Pattern]; ok {
+		f.regex = preset
+		return nil
+	}
+	var err error
+	if f.regex, err = regexp.Compile(f.Pattern); err != nil {
+		return fmt.Errorf("invalid file pattern %s: %w", f.Pattern, err)
+	}
+	return nil
+}
+
+func (f *FilePattern) appliesTo(filePath string) bool {
+	if f.Dir == "" {
+		return true
+	}
+	dir := path.Clean(f.Dir)
+	fileDir := path.Dir(path.Clean(filePath))
+	return fileDir == dir || strings.HasPrefix(fileDir, dir+"/")
+}
+
+func (f *FilePattern) defaultPublication(filePath string) string {
+	if f.Publication != "" || !f.PublicationFromDir {
+		return f.Publication
+	}
+	dir := path.Dir(path.Clean(filePath))
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return strings.Split(strings.TrimPrefix(dir, "/"), "/")[0]
+}
+
+type EpisodeName struct {
+	Publication string
+	Series      int32
+	Episode     int32
+}
+
+// FilePatterns are tried in order, using the first that matches.
+type FilePatterns []*FilePattern
+
+func DefaultFilePatterns() FilePatterns {
+	patterns, err := NewFilePatterns(&FilePattern{Pattern: "default"})
+	if err != nil {
+		// presets are always valid
+		panic(err)
+	}
+	return patterns
+}
+
+func NewFilePatterns(patterns ...*FilePattern) (FilePatterns, error) {
+	for _, v := range patterns {
+		if err := v.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return patterns, nil
+}
+
+// LoadFilePatterns reads patterns from a JSON file e.g.
+// [{"dir": "podcast", "publication": "pod", "pattern": "date"}, {"pattern": "short", "publication_from_dir": true}]
+// If no path is given, the default patterns are returned.
+func LoadFilePatterns(filePath string) (FilePatterns, error) {
+	if filePath == "" {
+		return DefaultFilePatterns(), nil
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file patterns %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	patterns := []*FilePattern{}
+	if err := json.NewDecoder(f).Decode(&patterns); err != nil {
+		return nil, fmt.Errorf("failed to decode file patterns %s: %w", filePath, err)
+	}
+	return NewFilePatterns(patterns...)
+}
+
+// Parse extracts the episode details from the given file path which should be relative to the media path.
+func (f FilePatterns) Parse(filePath string) (EpisodeName, error) {
+	fileName := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	for _, pattern := range f {
+		if !pattern.appliesTo(filePath) || !pattern.regex.MatchString(fileName) {
+			continue
+		}
+		return parseFileName(pattern.regex, fileName, pattern.defaultPublication(filePath))
+	}
+	return EpisodeName{}, fmt.Errorf("no file pattern matched file name %s", filePath)
+}
+
+func parseFileName(filePatternRegex *regexp.Regexp, filename string, defaultPublication string) (EpisodeName, error) {
+
+	match := filePatternRegex.FindStringSubmatch(filename)
+	if match == nil {
+		return EpisodeName{}, fmt.Errorf("failed to match file name %s", filename)
+	}
+	result := make(map[string]string)
+	for i, name := range filePatternRegex.SubexpNames() {
+		if i != 0 && name != "" {
+			result[name] = match[i]
+		}
+	}
+
+	name := EpisodeName{Publication: defaultPublication, Series: 1}
+	if publicationStr, ok := result["publication"]; ok && publicationStr != "" {
+		name.Publication = publicationStr
+	}
+	if name.Publication == "" {
+		return EpisodeName{}, fmt.Errorf("file pattern did not match [publication] and no default was configured: %s", filename)
+	}
+
+	// dated episodes use the year as the series and month+day as the episode e.g. 2024-01-30 -> S2024E130
+	// unless there is a more specific series or episode.
+	if dateStr, ok := result["date"]; ok && dateStr != "" {
+		date, err := time.Parse("20060102", strings.NewReplacer("-", "", "_", "", ".", "").Replace(dateStr))
+		if err != nil {
+			return EpisodeName{}, fmt.Errorf("failed to parse matched date %s: %w", dateStr, err)
+		}
+		name.Series = int32(date.Year())
+		name.Episode = int32(date.Month())*100 + int32(date.Day())
+	}
+
+	if seriesStr, ok := result["series"]; ok && seriesStr != "" {
+		seriesInt, err := strconv.ParseInt(seriesStr, 10, 32)
+		if err != nil {
+			return EpisodeName{}, fmt.Errorf("failed to parse matched series int %s: %w", seriesStr, err)
+		}
+		name.Series = int32(seriesInt)
+	}
+	if episodeStr, ok := result["episode"]; ok && episodeStr != "" {
+		episodeInt, err := strconv.ParseInt(episodeStr, 10, 32)
+		if err != nil {
+			return EpisodeName{}, fmt.Errorf("failed to parse matched episode int %s: %w", episodeStr, err)
+		}
+		name.Episode = int32(episodeInt)
+	} else if name.Episode == 0 {
+		return EpisodeName{}, fmt.Errorf("file pattern did not match [episode] or [date]: %s", filename)
+	}
+	return name, nil
+}
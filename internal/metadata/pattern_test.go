@@ -0,0 +1,114 @@
+package metadata
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFilePatterns_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []*FilePattern
+		filePath string
+		want     EpisodeName
+		wantErr  require.ErrorAssertionFunc
+	}{
+		{
+			name:     "default pattern",
+			patterns: []*FilePattern{{Pattern: "default"}},
+			filePath: "xfm-S01E02.srt",
+			want:     EpisodeName{Publication: "xfm", Series: 1, Episode: 2},
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "default pattern fails on unknown format",
+			patterns: []*FilePattern{{Pattern: "default"}},
+			filePath: "xfm-2024-01-30.srt",
+			wantErr:  require.Error,
+		},
+		{
+			name:     "series number above 10",
+			patterns: []*FilePattern{{Pattern: "x", Publication: "simpsons"}},
+			filePath: "The Simpsons - 10x01 - Lard of the Dance.srt",
+			want:     EpisodeName{Publication: "simpsons", Series: 10, Episode: 1},
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "missing publication fails",
+			patterns: []*FilePattern{{Pattern: "x"}},
+			filePath: "The Simpsons - 10x01 - Lard of the Dance.srt",
+			wantErr:  require.Error,
+		},
+		{
+			name:     "date pattern",
+			patterns: []*FilePattern{{Pattern: "date", Publication: "pod"}},
+			filePath: "pod 2024-01-30.srt",
+			want:     EpisodeName{Publication: "pod", Series: 2024, Episode: 130},
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "date with episode number",
+			patterns: []*FilePattern{{Pattern: `^(?P<date>\d{8})-(?P<episode>\d+)$`, Publication: "pod"}},
+			filePath: "20240130-55.srt",
+			want:     EpisodeName{Publication: "pod", Series: 2024, Episode: 55},
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "plain episode number defaults to series 1",
+			patterns: []*FilePattern{{Pattern: `^(?P<publication>[a-z]+)-(?P<episode>\d+)$`}},
+			filePath: "pod-123.srt",
+			want:     EpisodeName{Publication: "pod", Series: 1, Episode: 123},
+			wantErr:  require.NoError,
+		},
+		{
+			name: "patterns are limited to their directory",
+			patterns: []*FilePattern{
+				{Dir: "podcast", Pattern: `^(?P<episode>\d+)$`, Publication: "pod"},
+				{Dir: "other", Pattern: `^(?P<episode>\d+)$`, Publication: "other"},
+			},
+			filePath: "other/nested/12.srt",
+			want:     EpisodeName{Publication: "other", Series: 1, Episode: 12},
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "publication from top level directory",
+			patterns: []*FilePattern{{Pattern: "short", PublicationFromDir: true}},
+			filePath: "simpsons/season 10/The Simpsons S10E01.srt",
+			want:     EpisodeName{Publication: "simpsons", Series: 10, Episode: 1},
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "publication from directory fails without directory",
+			patterns: []*FilePattern{{Pattern: "short", PublicationFromDir: true}},
+			filePath: "The Simpsons S10E01.srt",
+			wantErr:  require.Error,
+		},
+		{
+			name:     "publication group overrides directory",
+			patterns: []*FilePattern{{Pattern: "default", PublicationFromDir: true}},
+			filePath: "radio/xfm-S01E02.srt",
+			want:     EpisodeName{Publication: "xfm", Series: 1, Episode: 2},
+			wantErr:  require.NoError,
+		},
+		{
+			name: "first matching pattern is used",
+			patterns: []*FilePattern{
+				{Pattern: `^(?P<episode>\d+)$`, Publication: "pod"},
+				{Pattern: "default"},
+			},
+			filePath: "xfm-S01E02.srt",
+			want:     EpisodeName{Publication: "xfm", Series: 1, Episode: 2},
+			wantErr:  require.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := NewFilePatterns(tt.patterns...)
+			require.NoError(t, err)
+
+			got, err := patterns.Parse(tt.filePath)
+			tt.wantErr(t, err)
+			require.EqualValues(t, tt.want, got)
+		})
+	}
+}
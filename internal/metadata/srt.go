@@ -2,111 +2,101 @@ package metadata
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/warmans/audio-search-bot/internal/audiometa"
 	"github.com/warmans/audio-search-bot/internal/model"
-	"github.com/warmans/audio-search-bot/internal/srt"
+	"github.com/warmans/audio-search-bot/internal/subtitle"
 	"os"
 	"path"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 )
 
-var filePatternRegex = regexp.MustCompile(`(?P<publication>[a-zA-Z0-9]+)-S(?P<series>\d+)E(?P<episode>\d+)\.srt`)
-
-const mediaFileExtension = ".mp3"
-
-func CreateMetadataFromSRT(srtPath, metadataDir string) (*model.Audio, error) {
+// CreateMetadataFromSRT creates the metadata for the given SRT and media file. The media file should be
+// relative to the media path. The probe result of the media file is optional but is required to populate the
+// duration, artwork and tags. The metadata is not stored, see WriteMetadata.
+func CreateMetadataFromSRT(srtPath string, mediaFile string, name EpisodeName, mediaMeta *audiometa.ProbeResult) (*model.Audio, error) {
 
 	srtName := path.Base(srtPath)
 
 	meta := &model.Audio{
-		SRTFile:   srtName,
-		MediaFile: fmt.Sprintf("%s.%s", strings.TrimSuffix(path.Base(srtName), ".srt"), strings.TrimPrefix(mediaFileExtension, ".")),
+		SRTFile:     srtName,
+		MediaFile:   mediaFile,
+		Publication: name.Publication,
+		Series:      name.Series,
+		Episode:     name.Episode,
+	}
+	if mediaMeta != nil {
+		meta.Duration = mediaMeta.Duration()
+		meta.Tags = mediaMeta.Format.Tags.TagMap()
+		if mediaMeta.HasArtwork() {
+			meta.ArtworkFile = audiometa.ArtworkPath(meta.MediaFile)
+		}
 	}
 	var err error
-	meta.Publication, meta.Series, meta.Episode, err = parseFileName(filePatternRegex, srtName)
+	meta.Dialog, meta.Warnings, err = parseSRT(srtPath)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to process SRT %s: %w", srtName, err)
 	}
-	fileName := fmt.Sprintf("%s.json", meta.ID())
-	metaPath := path.Join(metadataDir, fileName)
-
-	meta.Dialog, err = parseSRT(srtPath)
+	words, err := LoadWords(WordsPath(srtPath))
 	if err != nil {
-		return nil, fmt.Errorf("failed to process SRT %s: %w", srtName, err)
+		return nil, fmt.Errorf("failed to load words for SRT %s: %w", srtName, err)
 	}
-
-	if err := writeMetadata(metaPath, meta); err != nil {
-		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	if words != nil {
+		alignWords(meta.Dialog, words.Words)
+		meta.Chapters = words.Chapters
 	}
 	return meta, nil
 }
 
-func writeMetadata(path string, e *model.Audio) error {
+// WordsPath is the location of the word level transcript written alongside a transcribed SRT.
+func WordsPath(srtPath string) string {
+	return fmt.Sprintf("%s.words.json", strings.TrimSuffix(srtPath, path.Ext(srtPath)))
+}
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+// LoadWords returns nil if the SRT has no words e.g. because it was not created by a transcriber.
+func LoadWords(wordsPath string) (*model.Transcript, error) {
+	f, err := os.Open(wordsPath)
 	if err != nil {
-		return fmt.Errorf("failed to open file for writing %s: %w", path, err)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	defer file.Close()
-
-	enc := json.NewEncoder(file)
-	enc.SetIndent("", "  ")
+	defer f.Close()
 
-	return enc.Encode(e)
+	transcript := &model.Transcript{}
+	if err := json.NewDecoder(f).Decode(transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", wordsPath, err)
+	}
+	return transcript, nil
 }
 
-func parseFileName(filePatternRegex *regexp.Regexp, filename string) (string, int32, int32, error) {
-
-	match := filePatternRegex.FindStringSubmatch(filename)
-	if len(match) < 3 {
-		return "", 0, 0, fmt.Errorf("failed to match file name %s", filename)
-	}
-	result := make(map[string]string)
-	for i, name := range filePatternRegex.SubexpNames() {
-		if i != 0 && name != "" {
-			result[name] = match[i]
+// alignWords assigns each word to the line that contains the middle of the word. Lines may have been
+// edited since the SRT was created so words that do not fall within any line are dropped.
+func alignWords(dialog []model.Dialog, words []model.TranscriptWord) {
+	line := 0
+	for _, word := range words {
+		mid := word.Start + (word.End-word.Start)/2
+		for line < len(dialog) && dialog[line].EndTimestamp < mid {
+			line++
 		}
-	}
-
-	var err error
-	var seriesInt int64
-	if seriesStr, ok := result["series"]; ok && seriesStr != "" {
-		seriesInt, err = strconv.ParseInt(seriesStr, 10, 32)
-		if err != nil {
-			return "", 0, 0, fmt.Errorf("failed to parse matched series int %s: %w", seriesStr, err)
+		if line >= len(dialog) {
+			break
 		}
-	} else {
-		return "", 0, 0, fmt.Errorf("file pattern did not match series in : %s", filename)
-	}
-	var episodeInt int64
-	if episodeStr, ok := result["episode"]; ok && episodeStr != "" {
-		episodeInt, err = strconv.ParseInt(episodeStr, 10, 32)
-		if err != nil {
-			return "", 0, 0, fmt.Errorf("failed to parse matched episode int %s: %w", episodeStr, err)
+		if mid < dialog[line].StartTimestamp {
+			continue
 		}
-	} else {
-		return "", 0, 0, fmt.Errorf("file pattern did not match [episode]")
+		dialog[line].Words = append(dialog[line].Words, word)
 	}
-	publication := ""
-	if publicationStr, ok := result["publication"]; ok && publicationStr != "" {
-		publication = publicationStr
+	for k := range dialog {
+		dialog[k].Confidence = dialog[k].WordConfidence()
 	}
-	return publication, int32(seriesInt), int32(episodeInt), nil
 }
 
-func parseSRT(filePath string) ([]model.Dialog, error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open srt file %s: %w", filePath, err)
-	}
-	defer f.Close()
-
-	dialog, err := srt.Read(f, true, time.Second*30)
-	if err != nil {
-		return nil, err
-	}
-	return dialog, nil
+// parseSRT reads any supported subtitle format, not just SRT. Malformed SRT blocks are skipped with a warning
+// rather than failing the whole file.
+func parseSRT(filePath string) ([]model.Dialog, []model.ParseWarning, error) {
+	return subtitle.ReadFileLenient(filePath, true, time.Second*30)
 }
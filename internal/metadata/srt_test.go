@@ -0,0 +1,66 @@
+package metadata
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestCreateMetadataFromSRT_words(t *testing.T) {
+	dir := t.TempDir()
+	srtPath := path.Join(dir, "xfm-S01E01.srt")
+	require.NoError(t, os.WriteFile(srtPath, []byte("1\n00:00:01,000 --> 00:00:02,000\nfoo bar\n\n2\n00:00:03,000 --> 00:00:04,000\nbaz\n"), 0644))
+
+	word := func(text string, start, end time.Duration) model.TranscriptWord {
+		return model.TranscriptWord{Text: text, Start: start, End: end, Confidence: 1}
+	}
+	words := model.Transcript{Words: []model.TranscriptWord{
+		// removed from the SRT by hand
+		word("um", time.Millisecond*200, time.Millisecond*600),
+		word("foo", time.Millisecond*1000, time.Millisecond*1500),
+		word("bar", time.Millisecond*1500, time.Millisecond*2000),
+		word("baz", time.Millisecond*3000, time.Millisecond*4000),
+		word("qux", time.Millisecond*5000, time.Millisecond*6000),
+	}}
+	words.Words[3].Confidence = 0.5
+	words.Chapters = model.Chapters{{Headline: "Foo", Start: 0, End: time.Second * 6}}
+	data, err := json.Marshal(words)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(WordsPath(srtPath), data, 0644))
+
+	meta, err := CreateMetadataFromSRT(srtPath, "xfm-S01E01.mp3", EpisodeName{Publication: "xfm", Series: 1, Episode: 1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, WriteMetadata(dir, meta))
+	require.Len(t, meta.Dialog, 2)
+	require.EqualValues(t, words.Words[1:3], meta.Dialog[0].Words)
+	require.EqualValues(t, words.Words[3:4], meta.Dialog[1].Words)
+	require.EqualValues(t, 1, *meta.Dialog[0].Confidence)
+	require.EqualValues(t, 0.5, *meta.Dialog[1].Confidence)
+	require.EqualValues(t, words.Chapters, meta.Chapters)
+
+	// words are kept in the metadata
+	loaded, err := LoadMetadata(path.Join(dir, "xfm-S01E01.json"))
+	require.NoError(t, err)
+	require.EqualValues(t, meta.Dialog, loaded.Dialog)
+}
+
+func TestCreateMetadataFromSRT_warnings(t *testing.T) {
+	dir := t.TempDir()
+	srtPath := path.Join(dir, "xfm-S01E01.srt")
+	require.NoError(t, os.WriteFile(srtPath, []byte("00:00:01,000 --> 00:00:02,000\nfoo\n\n2\n00:00:03 --> 00:00:04,000\nbar\n"), 0644))
+
+	meta, err := CreateMetadataFromSRT(srtPath, "xfm-S01E01.mp3", EpisodeName{Publication: "xfm", Series: 1, Episode: 1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, WriteMetadata(dir, meta))
+	require.Len(t, meta.Dialog, 1)
+	require.Len(t, meta.Warnings, 2)
+	require.EqualValues(t, model.ParseWarning{Line: 1, Message: "missing index"}, meta.Warnings[0])
+
+	loaded, err := LoadMetadata(path.Join(dir, "xfm-S01E01.json"))
+	require.NoError(t, err)
+	require.EqualValues(t, meta.Warnings, loaded.Warnings)
+}
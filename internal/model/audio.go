@@ -19,26 +19,91 @@ type Dialog struct {
 	EndTimestamp   time.Duration `json:"end_timestamp" db:"end_timestamp"`
 	Content        string        `json:"content" db:"content"`
 	MediaFileName  string        `json:"media_file_name" db:"media_file_name"`
+	// Words are only available if the dialog was transcribed with word level timestamps.
+	Words []TranscriptWord `json:"words,omitempty" db:"-"`
+	// Speaker is only available if the subtitle format identifies speakers.
+	Speaker string `json:"speaker,omitempty" db:"speaker"`
+	// Confidence is the mean confidence of the words, if the transcriber gave one.
+	Confidence *float64 `json:"confidence,omitempty" db:"confidence"`
 }
 
 func (e *Dialog) ID(episodeID string) string {
 	return fmt.Sprintf("%s-%d", episodeID, e.Pos)
 }
 
+// WordConfidence is the mean confidence of the words or nil if there are no words.
+func (e *Dialog) WordConfidence() *float64 {
+	if len(e.Words) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, w := range e.Words {
+		total += w.Confidence
+	}
+	mean := total / float64(len(e.Words))
+	return &mean
+}
+
 type Audio struct {
-	SRTFile     string    `json:"srt_file"`
-	SRTModTime  time.Time `json:"srt_mod_time"`
-	MediaFile   string    `json:"media_file"`
-	Publication string    `json:"publication"`
-	Series      int32     `json:"season"`
-	Episode     int32     `json:"episode"`
-	Dialog      []Dialog  `json:"dialog"`
+	SRTFile     string            `json:"srt_file"`
+	SRTModTime  time.Time         `json:"srt_mod_time"`
+	MediaFile   string            `json:"media_file"`
+	Publication string            `json:"publication"`
+	Series      int32             `json:"season"`
+	Episode     int32             `json:"episode"`
+	Duration    time.Duration     `json:"duration,omitempty"`
+	ArtworkFile string            `json:"artwork_file,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Chapters    Chapters          `json:"chapters,omitempty"`
+	Dialog      []Dialog          `json:"dialog"`
+	// Warnings are problems with the subtitles that were repaired or skipped when the metadata was created.
+	Warnings []ParseWarning `json:"warnings,omitempty"`
+}
+
+// ParseWarning is a problem found at the given line of a subtitle file that did not prevent it being read.
+type ParseWarning struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+	// Skipped is true if the block could not be repaired and is missing from the dialog.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
 }
 
 func (a *Audio) ID() string {
 	return fmt.Sprintf("%s-%s", a.Publication, util.FormatSeriesAndEpisode(a.Series, a.Episode))
 }
 
+func (a *Audio) ToEpisode() Episode {
+	return Episode{
+		ID:              a.ID(),
+		Publication:     a.Publication,
+		Series:          a.Series,
+		Episode:         a.Episode,
+		MediaFileName:   a.MediaFile,
+		Duration:        a.Duration,
+		ArtworkFileName: a.ArtworkFile,
+		Tags:            a.Tags,
+		Chapters:        a.Chapters,
+	}
+}
+
+type Episode struct {
+	ID              string            `json:"id"`
+	Publication     string            `json:"publication"`
+	Series          int32             `json:"series"`
+	Episode         int32             `json:"episode"`
+	MediaFileName   string            `json:"media_file_name"`
+	Duration        time.Duration     `json:"duration"`
+	ArtworkFileName string            `json:"artwork_file_name"`
+	Tags            map[string]string `json:"tags"`
+	Chapters        Chapters          `json:"chapters"`
+	// Timing is set by an admin rather than imported.
+	Timing TimingCorrection `json:"timing"`
+}
+
 type Publication struct {
 	Name   string   `json:"name"`
 	Series []string `json:"series"`
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Chapter is a segment of an episode about a single topic.
+type Chapter struct {
+	Headline string        `json:"headline"`
+	Summary  string        `json:"summary,omitempty"`
+	Start    time.Duration `json:"start"`
+	End      time.Duration `json:"end"`
+}
+
+type Chapters []Chapter
+
+// At returns the chapter containing the timestamp or nil if there isn't one.
+func (c Chapters) At(ts time.Duration) *Chapter {
+	for k := range c {
+		if ts >= c[k].Start && ts < c[k].End {
+			return &c[k]
+		}
+	}
+	return nil
+}
+
+// HeadlineAt returns the headline of the chapter containing the timestamp or an empty string.
+func (c Chapters) HeadlineAt(ts time.Duration) string {
+	if chapter := c.At(ts); chapter != nil {
+		return chapter.Headline
+	}
+	return ""
+}
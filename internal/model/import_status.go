@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type ImportPhase string
+
+const (
+	ImportPhaseStarting  ImportPhase = "starting"
+	ImportPhaseScanning  ImportPhase = "scanning"
+	ImportPhaseImporting ImportPhase = "importing"
+	ImportPhaseIdle      ImportPhase = "idle"
+)
+
+// ImportStatus is a snapshot of the importer's progress. Done and Failed are totals since the importer started.
+type ImportStatus struct {
+	Phase  ImportPhase `json:"phase"`
+	Queued int         `json:"queued"`
+	Done   int         `json:"done"`
+	Failed int         `json:"failed"`
+	// LastError is the most recent file or sync error.
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time"`
+	// LastSync is when a sync last completed without error. Individual files may still have been quarantined.
+	LastSync           time.Time `json:"last_sync"`
+	IndexDocumentCount uint64    `json:"index_document_count"`
+	// SkippedBlocks is the total number of malformed subtitle blocks that were left out of imported files. Each
+	// file's blocks are listed in the warnings of its metadata.
+	SkippedBlocks   int    `json:"skipped_blocks"`
+	LastSkippedFile string `json:"last_skipped_file,omitempty"`
+}
+
+// SkippedBlocks is the number of warnings for blocks that were left out of the dialog.
+func SkippedBlocks(warnings []ParseWarning) int {
+	skipped := 0
+	for _, w := range warnings {
+		if w.Skipped {
+			skipped++
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// TimingCorrection fixes subtitles that are consistently shifted or that drift, usually because they were timed
+// against a different frame rate. Timestamps are scaled before the offset is added. A zero scale is the same as 1.
+type TimingCorrection struct {
+	Offset time.Duration `json:"offset"`
+	Scale  float64       `json:"scale"`
+}
+
+func (c TimingCorrection) IsZero() bool {
+	return c.Offset == 0 && (c.Scale == 0 || c.Scale == 1)
+}
+
+func (c TimingCorrection) Apply(ts time.Duration) time.Duration {
+	if c.Scale != 0 {
+		ts = time.Duration(float64(ts) * c.Scale)
+	}
+	return max(ts+c.Offset, 0)
+}
+
+// ApplyDialog corrects the line timestamps. Word timestamps come from the audio so are not changed.
+func (c TimingCorrection) ApplyDialog(dialog []Dialog) {
+	if c.IsZero() {
+		return
+	}
+	for k := range dialog {
+		dialog[k].StartTimestamp = c.Apply(dialog[k].StartTimestamp)
+		dialog[k].EndTimestamp = c.Apply(dialog[k].EndTimestamp)
+	}
+}
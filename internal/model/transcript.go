@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Transcript is the word level output of a transcriber, independent of the backend.
+type Transcript struct {
+	Words []TranscriptWord `json:"words"`
+	// Chapters are only available if the backend summarises the transcript.
+	Chapters Chapters `json:"chapters,omitempty"`
+}
+
+// Duration is the end of the last word.
+func (t *Transcript) Duration() time.Duration {
+	if len(t.Words) == 0 {
+		return 0
+	}
+	return t.Words[len(t.Words)-1].End
+}
+
+type TranscriptWord struct {
+	Text  string        `json:"text"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	// Confidence is between 0 and 1.
+	Confidence float64 `json:"confidence"`
+	// Speaker is empty if the backend does not identify speakers.
+	Speaker string `json:"speaker,omitempty"`
+}
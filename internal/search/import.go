@@ -28,6 +28,11 @@ func getMappedField(fieldName string, t mapping.FieldType, d searchModel.DialogD
 			return bluge.NewNumericField(fieldName, float64(typed)).StoreValue(), true
 		case float64:
 			return bluge.NewNumericField(fieldName, typed).StoreValue(), true
+		case *float64:
+			if typed == nil {
+				return nil, false
+			}
+			return bluge.NewNumericField(fieldName, *typed).StoreValue(), true
 		case int32:
 			return bluge.NewNumericField(fieldName, float64(typed)).StoreValue(), true
 		case int64:
@@ -71,6 +76,8 @@ func DocumentsFromModel(episode *model.Audio) []searchModel.DialogDocument {
 			EndTimestamp:   v.EndTimestamp.Milliseconds(),
 			MediaFileName:  episode.MediaFile,
 			Content:        v.Content,
+			Chapter:        episode.Chapters.HeadlineAt(v.StartTimestamp),
+			Confidence:     v.Confidence,
 		})
 	}
 	return docs
@@ -0,0 +1,314 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	metaModel "github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/search/mapping"
+	"github.com/warmans/audio-search-bot/internal/search/model"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var memoryWord = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// NewMemorySearch creates a searcher that holds all documents in memory. It is intended for tests
+// and embedding where a bluge index or sqlite DB is not available.
+func NewMemorySearch(audio ...metaModel.Audio) *MemorySearch {
+	s := &MemorySearch{lock: &sync.RWMutex{}}
+	for _, v := range audio {
+		s.add(&v)
+	}
+	return s
+}
+
+type MemorySearch struct {
+	lock *sync.RWMutex
+	docs []model.DialogDocument
+}
+
+func (m *MemorySearch) Get(ctx context.Context, id string) (*model.DialogDocument, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, v := range m.docs {
+		if v.ID == id {
+			doc := v
+			return &doc, nil
+		}
+	}
+	return nil, fmt.Errorf("no match found")
+}
+
+func (m *MemorySearch) Search(ctx context.Context, f []searchterms.Term, overrides ...Override) ([]model.DialogDocument, error) {
+
+	opts := resolveOverrides(overrides)
+
+	terms, offset := searchterms.ExtractOffset(f)
+
+	setFrom := 0
+	if offset != nil {
+		setFrom = int(*offset)
+	}
+
+	pageSize := DefaultPageSize
+	if opts.pageSize != nil {
+		pageSize = *opts.pageSize
+	}
+
+	// like the bluge index, a query with no terms matches nothing.
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var results []model.DialogDocument
+	matched := 0
+	for _, doc := range m.docs {
+		ok, err := matchesAll(&doc, terms)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		matched++
+		if matched <= setFrom {
+			continue
+		}
+		results = append(results, doc)
+		if len(results) >= pageSize {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (m *MemorySearch) ListTerms(ctx context.Context, fieldName string) ([]string, error) {
+	fieldType, ok := (&model.DialogDocument{}).FieldMapping()[fieldName]
+	if !ok || fieldType != mapping.FieldTypeKeyword {
+		return nil, fmt.Errorf("cannot list terms for field '%s'", fieldName)
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	terms := []string{}
+	for _, doc := range m.docs {
+		term := fmt.Sprintf("%v", doc.GetNamedField(fieldName))
+		if strings.TrimSpace(term) == "" || slices.Contains(terms, term) {
+			continue
+		}
+		terms = append(terms, term)
+		if len(terms) > 100 {
+			return nil, fmt.Errorf("too many terms for field '%s' returned", fieldName)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		return terms[i] > terms[j]
+	})
+	return terms, nil
+}
+
+func (m *MemorySearch) Import(ctx context.Context, meta *metaModel.Audio, deleteFirst bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if deleteFirst {
+		m.docs = slices.DeleteFunc(m.docs, func(doc model.DialogDocument) bool {
+			return doc.MediaID == meta.ID()
+		})
+	}
+	m.add(meta)
+	return nil
+}
+
+// RefreshIndex does nothing since writes are immediately visible.
+func (m *MemorySearch) RefreshIndex() error {
+	return nil
+}
+
+func (m *MemorySearch) Count(ctx context.Context) (uint64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return uint64(len(m.docs)), nil
+}
+
+func (m *MemorySearch) add(meta *metaModel.Audio) {
+	for _, doc := range DocumentsFromModel(meta) {
+		idx := slices.IndexFunc(m.docs, func(existing model.DialogDocument) bool {
+			return existing.ID == doc.ID
+		})
+		if idx >= 0 {
+			m.docs[idx] = doc
+		} else {
+			m.docs = append(m.docs, doc)
+		}
+	}
+	sort.SliceStable(m.docs, func(i, j int) bool {
+		if m.docs[i].MediaID == m.docs[j].MediaID {
+			return m.docs[i].Pos < m.docs[j].Pos
+		}
+		return m.docs[i].MediaID < m.docs[j].MediaID
+	})
+}
+
+func matchesAll(doc *model.DialogDocument, terms []searchterms.Term) (bool, error) {
+	for _, term := range terms {
+		ok, err := matchesTerm(doc, term)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchesTerm(doc *model.DialogDocument, term searchterms.Term) (bool, error) {
+	fieldType, ok := doc.FieldMapping()[term.Field]
+	if !ok {
+		return false, fmt.Errorf("unknown field %s", term.Field)
+	}
+	fieldValue := doc.GetNamedField(term.Field)
+	if optional, ok := fieldValue.(*float64); ok && optional == nil {
+		// documents without a value never match e.g. dialog with an unknown confidence
+		return false, nil
+	}
+
+	switch term.Op {
+	case searchterms.CompOpEq:
+		return matchesEq(fieldType, fieldValue, term.Value)
+	case searchterms.CompOpNeq:
+		ok, err := matchesEq(fieldType, fieldValue, term.Value)
+		return !ok, err
+	case searchterms.CompOpLike, searchterms.CompOpFuzzyLike:
+		if term.Value.Type() != searchterms.StringType {
+			return false, fmt.Errorf("could not compare text field %s with %s", term.Field, term.Value.Type())
+		}
+		maxDistance := 0
+		if term.Op == searchterms.CompOpFuzzyLike {
+			maxDistance = 1
+		}
+		fieldWords := tokenize(fmt.Sprintf("%v", fieldValue))
+		for _, want := range tokenize(term.Value.Value().(string)) {
+			for _, got := range fieldWords {
+				if editDistanceWithin(want, got, maxDistance) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case searchterms.CompOpGt, searchterms.CompOpGe, searchterms.CompOpLt, searchterms.CompOpLe:
+		cmp, err := compare(fieldType, fieldValue, term.Value)
+		if err != nil {
+			return false, err
+		}
+		switch term.Op {
+		case searchterms.CompOpGt:
+			return cmp > 0, nil
+		case searchterms.CompOpGe:
+			return cmp >= 0, nil
+		case searchterms.CompOpLt:
+			return cmp < 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	default:
+		return false, fmt.Errorf("operation %s was not implemented", string(term.Op))
+	}
+}
+
+func matchesEq(fieldType mapping.FieldType, fieldValue any, value searchterms.Value) (bool, error) {
+	if fieldType == mapping.FieldTypeText {
+		if value.Type() != searchterms.StringType {
+			return false, fmt.Errorf("could not compare text field with %s", value.Type())
+		}
+		phrase := tokenize(value.Value().(string))
+		words := tokenize(fmt.Sprintf("%v", fieldValue))
+		if len(phrase) == 0 {
+			return false, nil
+		}
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			if slices.Equal(words[i:i+len(phrase)], phrase) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	cmp, err := compare(fieldType, fieldValue, value)
+	if err != nil {
+		return false, err
+	}
+	return cmp == 0, nil
+}
+
+func compare(fieldType mapping.FieldType, fieldValue any, value searchterms.Value) (int, error) {
+	if fieldType == mapping.FieldTypeNumber {
+		var want float64
+		switch value.Type() {
+		case searchterms.IntType:
+			want = float64(value.Value().(int64))
+		case searchterms.DurationType:
+			want = float64(value.Value().(time.Duration).Milliseconds())
+		case searchterms.FloatType:
+			want = value.Value().(float64)
+		default:
+			return 0, fmt.Errorf("cannot compare number to %s", value.Type())
+		}
+		var got float64
+		switch typed := fieldValue.(type) {
+		case int32:
+			got = float64(typed)
+		case int64:
+			got = float64(typed)
+		case *float64:
+			got = *typed
+		default:
+			return 0, fmt.Errorf("non-numeric type mapped as number")
+		}
+		switch {
+		case got < want:
+			return -1, nil
+		case got > want:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	if value.Type() != searchterms.StringType {
+		return 0, fmt.Errorf("could not compare keyword field with %s", value.Type())
+	}
+	return strings.Compare(fmt.Sprintf("%v", fieldValue), value.Value().(string)), nil
+}
+
+func tokenize(s string) []string {
+	return memoryWord.FindAllString(strings.ToLower(s), -1)
+}
+
+// editDistanceWithin is true if the levenshtein distance between the two strings is at most maxDistance.
+func editDistanceWithin(a, b string, maxDistance int) bool {
+	if maxDistance == 0 {
+		return a == b
+	}
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)] <= maxDistance
+}
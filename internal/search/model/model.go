@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/blugelabs/bluge"
 	"github.com/warmans/audio-search-bot/internal/search/mapping"
+	"github.com/warmans/audio-search-bot/internal/util"
 	"time"
 )
 
@@ -17,6 +18,10 @@ type DialogDocument struct {
 	EndTimestamp   int64  `json:"end_timestamp"`
 	MediaFileName  string `json:"video_file_name"`
 	Content        string `json:"content"`
+	// Chapter is the headline of the chapter containing the dialog, if the episode has chapters.
+	Chapter string `json:"chapter,omitempty"`
+	// Confidence is nil if the transcription confidence is not known.
+	Confidence *float64 `json:"confidence,omitempty"`
 }
 
 func (d *DialogDocument) FieldMapping() map[string]mapping.FieldType {
@@ -31,6 +36,8 @@ func (d *DialogDocument) FieldMapping() map[string]mapping.FieldType {
 		"end_timestamp":   mapping.FieldTypeNumber,
 		"media_file_name": mapping.FieldTypeText,
 		"content":         mapping.FieldTypeText,
+		"chapter":         mapping.FieldTypeText,
+		"confidence":      mapping.FieldTypeNumber,
 	}
 }
 
@@ -60,6 +67,10 @@ func (d *DialogDocument) GetNamedField(name string) any {
 		return d.MediaFileName
 	case "content":
 		return d.Content
+	case "chapter":
+		return d.Chapter
+	case "confidence":
+		return d.Confidence
 	}
 	return ""
 }
@@ -86,6 +97,10 @@ func (d *DialogDocument) SetNamedField(name string, value any) {
 		d.MediaFileName = string(value.([]byte))
 	case "content":
 		d.Content = string(value.([]byte))
+	case "chapter":
+		d.Chapter = string(value.([]byte))
+	case "confidence":
+		d.Confidence = util.ToPtr(bytesToFloatOrZero(value))
 	}
 }
 
@@ -47,6 +47,15 @@ type Searcher interface {
 	ListTerms(ctx context.Context, field string) ([]string, error)
 }
 
+// Index is a Searcher that can be populated by the importer.
+type Index interface {
+	Searcher
+	Import(ctx context.Context, meta *metaModel.Audio, deleteFirst bool) error
+	RefreshIndex() error
+	// Count returns the number of indexed dialog documents.
+	Count(ctx context.Context) (uint64, error)
+}
+
 func NewBlugeSearch(indexPath string) (*BlugeSearch, error) {
 	s := &BlugeSearch{
 		indexReadLock: &sync.RWMutex{},
@@ -84,6 +93,20 @@ func (b *BlugeSearch) withSnapshot(fn func(r *bluge.Reader) error) error {
 	return fn(b.index)
 }
 
+func (b *BlugeSearch) Count(ctx context.Context) (uint64, error) {
+	var count uint64
+	err := b.withSnapshot(func(r *bluge.Reader) error {
+		if r == nil {
+			// index hasn't been initialized yet
+			return nil
+		}
+		var err error
+		count, err = r.Count()
+		return err
+	})
+	return count, err
+}
+
 func (b *BlugeSearch) Get(ctx context.Context, id string) (*model.DialogDocument, error) {
 	q, _, err := bluge_query.NewBlugeQuery([]searchterms.Term{{Field: "_id", Value: searchterms.String(id), Op: searchterms.CompOpEq}})
 	if err != nil {
@@ -177,7 +200,6 @@ func (b *BlugeSearch) ListTerms(ctx context.Context, fieldName string) ([]string
 		}()
 
 		tfd, err := fieldDict.Next()
-		terms := []string{}
 		for err == nil && tfd != nil && strings.TrimSpace(tfd.Term()) != "" {
 			terms = append(terms, tfd.Term())
 			if len(terms) > 100 {
@@ -0,0 +1,47 @@
+package search_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/search"
+	"github.com/warmans/audio-search-bot/internal/search/searchtest"
+	"github.com/warmans/audio-search-bot/internal/store"
+	"path"
+	"testing"
+)
+
+func TestBlugeSearch(t *testing.T) {
+	searchtest.Run(t, func(t *testing.T, audio []model.Audio) search.Searcher {
+		searcher, err := search.NewBlugeSearch(path.Join(t.TempDir(), "metadata.bluge"))
+		require.NoError(t, err)
+		for _, v := range audio {
+			require.NoError(t, searcher.Import(context.Background(), &v, false))
+			require.NoError(t, searcher.RefreshIndex())
+		}
+		return searcher
+	})
+}
+
+func TestSqliteSearch(t *testing.T) {
+	searchtest.Run(t, func(t *testing.T, audio []model.Audio) search.Searcher {
+		conn, err := store.NewConn(&store.Config{DSN: path.Join(t.TempDir(), "dialog.sqlite3")})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, conn.Close())
+		})
+		require.NoError(t, conn.Migrate())
+
+		srtStore := store.NewSRTStore(conn.Db)
+		for _, v := range audio {
+			require.NoError(t, srtStore.ImportMedia(v))
+		}
+		return search.NewSqliteSearch(conn.Db)
+	})
+}
+
+func TestMemorySearch(t *testing.T) {
+	searchtest.Run(t, func(t *testing.T, audio []model.Audio) search.Searcher {
+		return search.NewMemorySearch(audio...)
+	})
+}
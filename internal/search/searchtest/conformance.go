@@ -0,0 +1,289 @@
+package searchtest
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/search"
+	searchModel "github.com/warmans/audio-search-bot/internal/search/model"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"testing"
+	"time"
+)
+
+// Fixtures returns the audio that is indexed for the conformance tests.
+func Fixtures() []model.Audio {
+	return []model.Audio{
+		{
+			SRTFile:     "xfm-S01E01.srt",
+			MediaFile:   "xfm-S01E01.mp3",
+			Publication: "xfm",
+			Series:      1,
+			Episode:     1,
+			Chapters: model.Chapters{
+				{Headline: "Karl explains monkey news", Start: time.Second * 60, End: time.Second * 80},
+			},
+			Dialog: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 2, Content: "Man alive", Confidence: util.ToPtr(0.9)},
+				{Pos: 2, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 10, Content: "The day man, fighter of the night man", Confidence: util.ToPtr(0.4)},
+				{Pos: 3, StartTimestamp: time.Second * 70, EndTimestamp: time.Second * 75, Content: "Fish and chips"},
+			},
+		},
+		{
+			SRTFile:     "xfm-S02E01.srt",
+			MediaFile:   "xfm-S02E01.mp3",
+			Publication: "xfm",
+			Series:      2,
+			Episode:     1,
+			Dialog: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 5, Content: "What a day it was, man"},
+				{Pos: 2, StartTimestamp: time.Second * 90, EndTimestamp: time.Second * 95, Content: "Alive and well"},
+			},
+		},
+		{
+			SRTFile:     "radio-S01E01.srt",
+			MediaFile:   "radio-S01E01.mp3",
+			Publication: "radio",
+			Series:      1,
+			Episode:     1,
+			Dialog: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 3, Content: "Day man"},
+				{Pos: 2, StartTimestamp: time.Second * 61, EndTimestamp: time.Second * 65, Content: "Monkey news"},
+			},
+		},
+	}
+}
+
+// Run verifies a searcher populated with the Fixtures has the same query semantics as every
+// other search.Searcher implementation.
+func Run(t *testing.T, newSearcher func(t *testing.T, audio []model.Audio) search.Searcher) {
+
+	searcher := newSearcher(t, Fixtures())
+	ctx := context.Background()
+
+	t.Run("search", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			query   string
+			wantIDs []string
+		}{
+			{
+				name:    "empty query matches nothing",
+				query:   "",
+				wantIDs: []string{},
+			},
+			{
+				name:    "word match",
+				query:   "alive",
+				wantIDs: []string{"xfm-S01E01-1", "xfm-S02E01-2"},
+			},
+			{
+				name:    "word match is case insensitive",
+				query:   "ALIVE",
+				wantIDs: []string{"xfm-S01E01-1", "xfm-S02E01-2"},
+			},
+			{
+				name:    "any word matches",
+				query:   "chips monkey",
+				wantIDs: []string{"xfm-S01E01-3", "radio-S01E01-2"},
+			},
+			{
+				name:    "phrase match",
+				query:   `"day man"`,
+				wantIDs: []string{"xfm-S01E01-2", "radio-S01E01-1"},
+			},
+			{
+				name:    "phrase match requires word order",
+				query:   `"man day"`,
+				wantIDs: []string{},
+			},
+			{
+				name:    "multiple content terms must all match",
+				query:   `"day man" night`,
+				wantIDs: []string{"xfm-S01E01-2"},
+			},
+			{
+				name:    "publication filter",
+				query:   `~xfm "day man"`,
+				wantIDs: []string{"xfm-S01E01-2"},
+			},
+			{
+				name:    "series filter",
+				query:   `#s2 man`,
+				wantIDs: []string{"xfm-S02E01-1"},
+			},
+			{
+				name:    "series and episode filter",
+				query:   `#s1e1 alive`,
+				wantIDs: []string{"xfm-S01E01-1"},
+			},
+			{
+				name:    "episode filter",
+				query:   `#e1 news`,
+				wantIDs: []string{"radio-S01E01-2"},
+			},
+			{
+				name:    "timestamp filter",
+				query:   `+1m alive`,
+				wantIDs: []string{"xfm-S02E01-2"},
+			},
+			{
+				name:    "timestamp filter is inclusive",
+				query:   `+61s news`,
+				wantIDs: []string{"radio-S01E01-2"},
+			},
+			{
+				name:    "chapter filter",
+				query:   `chapter:"monkey news"`,
+				wantIDs: []string{"xfm-S01E01-3"},
+			},
+			{
+				name:    "chapter filter with content",
+				query:   `chapter:monkey chips`,
+				wantIDs: []string{"xfm-S01E01-3"},
+			},
+			{
+				name:    "chapter filter does not match content",
+				query:   `chapter:day`,
+				wantIDs: []string{},
+			},
+			{
+				name:    "confidence filter",
+				query:   `man confidence<0.6`,
+				wantIDs: []string{"xfm-S01E01-2"},
+			},
+			{
+				name:    "confidence filter ignores unknown confidence",
+				query:   `confidence>=0.4 alive`,
+				wantIDs: []string{"xfm-S01E01-1"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res, err := searcher.Search(ctx, searchterms.MustParse(tt.query))
+				require.NoError(t, err)
+				require.ElementsMatch(t, tt.wantIDs, documentIDs(res))
+			})
+		}
+	})
+
+	t.Run("search operators", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			terms   []searchterms.Term
+			wantIDs []string
+		}{
+			{
+				name: "not equal",
+				terms: []searchterms.Term{
+					{Field: "content", Value: searchterms.String("alive"), Op: searchterms.CompOpFuzzyLike},
+					{Field: "publication", Value: searchterms.String("xfm"), Op: searchterms.CompOpEq},
+					{Field: "series", Value: searchterms.Int(1), Op: searchterms.CompOpNeq},
+				},
+				wantIDs: []string{"xfm-S02E01-2"},
+			},
+			{
+				name: "not equal phrase",
+				terms: []searchterms.Term{
+					{Field: "content", Value: searchterms.String("man"), Op: searchterms.CompOpLike},
+					{Field: "content", Value: searchterms.String("day man"), Op: searchterms.CompOpNeq},
+				},
+				wantIDs: []string{"xfm-S01E01-1", "xfm-S02E01-1"},
+			},
+			{
+				name: "less than",
+				terms: []searchterms.Term{
+					{Field: "content", Value: searchterms.String("man"), Op: searchterms.CompOpLike},
+					{Field: "start_timestamp", Value: searchterms.Duration(time.Second * 2), Op: searchterms.CompOpLt},
+				},
+				wantIDs: []string{"xfm-S01E01-1", "xfm-S02E01-1", "radio-S01E01-1"},
+			},
+			{
+				name: "less than or equal",
+				terms: []searchterms.Term{
+					{Field: "content", Value: searchterms.String("man"), Op: searchterms.CompOpLike},
+					{Field: "start_timestamp", Value: searchterms.Duration(time.Second * 2), Op: searchterms.CompOpLe},
+				},
+				wantIDs: []string{"xfm-S01E01-1", "xfm-S01E01-2", "xfm-S02E01-1", "radio-S01E01-1"},
+			},
+			{
+				name: "greater than",
+				terms: []searchterms.Term{
+					{Field: "content", Value: searchterms.String("man"), Op: searchterms.CompOpLike},
+					{Field: "pos", Value: searchterms.Int(1), Op: searchterms.CompOpGt},
+				},
+				wantIDs: []string{"xfm-S01E01-2"},
+			},
+			{
+				name: "keyword equal",
+				terms: []searchterms.Term{
+					{Field: "media_id", Value: searchterms.String("radio-S01E01"), Op: searchterms.CompOpEq},
+					{Field: "pos", Value: searchterms.Int(2), Op: searchterms.CompOpEq},
+				},
+				wantIDs: []string{"radio-S01E01-2"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res, err := searcher.Search(ctx, tt.terms)
+				require.NoError(t, err)
+				require.ElementsMatch(t, tt.wantIDs, documentIDs(res))
+			})
+		}
+	})
+
+	t.Run("search paging", func(t *testing.T) {
+		res, err := searcher.Search(ctx, searchterms.MustParse("man"))
+		require.NoError(t, err)
+		require.Len(t, res, 4)
+
+		res, err = searcher.Search(ctx, searchterms.MustParse("man"), search.OverridePageSize(3))
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+
+		res, err = searcher.Search(ctx, searchterms.MustParse("man >3"))
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		res, err = searcher.Search(ctx, searchterms.MustParse("man >4"))
+		require.NoError(t, err)
+		require.Len(t, res, 0)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		fixture := Fixtures()[0]
+		doc, err := searcher.Get(ctx, "xfm-S01E01-2")
+		require.NoError(t, err)
+		require.EqualValues(t, search.DocumentsFromModel(&fixture)[1], *doc)
+	})
+
+	t.Run("get unknown document fails", func(t *testing.T) {
+		_, err := searcher.Get(ctx, "xfm-S09E09-1")
+		require.Error(t, err)
+	})
+
+	t.Run("list terms", func(t *testing.T) {
+		terms, err := searcher.ListTerms(ctx, "publication")
+		require.NoError(t, err)
+		require.EqualValues(t, []string{"xfm", "radio"}, terms)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		index, ok := searcher.(search.Index)
+		if !ok {
+			t.Skip("searcher is not an index")
+		}
+		count, err := index.Count(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 7, count)
+	})
+}
+
+func documentIDs(docs []searchModel.DialogDocument) []string {
+	ids := []string{}
+	for _, v := range docs {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	metaModel "github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/search/mapping"
+	"github.com/warmans/audio-search-bot/internal/search/model"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"github.com/warmans/audio-search-bot/internal/searchterms/sqlite_query"
+)
+
+const sqliteDocumentColumns = `id, pos, media_id, publication, series, episode, start_timestamp, end_timestamp, media_file_name, confidence, chapter, content`
+
+// NewSqliteSearch creates a searcher backed by the dialog_fts table. The table is populated by
+// store.SRTStore.ImportMedia so no separate index needs to be maintained.
+func NewSqliteSearch(db sqlx.QueryerContext) *SqliteSearch {
+	return &SqliteSearch{db: db}
+}
+
+type SqliteSearch struct {
+	db sqlx.QueryerContext
+}
+
+func (s *SqliteSearch) Get(ctx context.Context, id string) (*model.DialogDocument, error) {
+	row := s.db.QueryRowxContext(ctx, fmt.Sprintf(`SELECT %s FROM dialog_fts WHERE id = $1`, sqliteDocumentColumns), id)
+	doc, err := scanSqliteDocument(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no match found")
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *SqliteSearch) Search(ctx context.Context, f []searchterms.Term, overrides ...Override) ([]model.DialogDocument, error) {
+
+	opts := resolveOverrides(overrides)
+
+	where, params, offset, err := sqlite_query.NewSqliteQuery(f)
+	if err != nil {
+		return nil, err
+	}
+
+	setFrom := int64(0)
+	if offset != nil {
+		setFrom = *offset
+	}
+
+	pageSize := DefaultPageSize
+	if opts.pageSize != nil {
+		pageSize = *opts.pageSize
+	}
+
+	rows, err := s.db.QueryxContext(
+		ctx,
+		fmt.Sprintf(`SELECT %s FROM dialog_fts WHERE %s ORDER BY rank, media_id, pos LIMIT %d OFFSET %d`, sqliteDocumentColumns, where, pageSize, setFrom),
+		params...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []model.DialogDocument
+	for rows.Next() {
+		doc, err := scanSqliteDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		results = append(results, *doc)
+	}
+	return results, rows.Err()
+}
+
+func (s *SqliteSearch) ListTerms(ctx context.Context, fieldName string) ([]string, error) {
+	if fieldType, ok := (&model.DialogDocument{}).FieldMapping()[fieldName]; !ok || fieldType != mapping.FieldTypeKeyword {
+		return nil, fmt.Errorf("cannot list terms for field '%s'", fieldName)
+	}
+	column := fieldName
+	if column == "_id" {
+		column = "id"
+	}
+	rows, err := s.db.QueryxContext(ctx, fmt.Sprintf(`SELECT DISTINCT %s FROM dialog_fts ORDER BY 1 DESC LIMIT 102`, column))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := []string{}
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if len(terms) > 100 {
+			return nil, fmt.Errorf("too many terms for field '%s' returned", fieldName)
+		}
+	}
+	return terms, rows.Err()
+}
+
+// Import does nothing since the dialog_fts table is updated along with the dialog table.
+func (s *SqliteSearch) Import(ctx context.Context, meta *metaModel.Audio, deleteFirst bool) error {
+	return nil
+}
+
+// RefreshIndex does nothing since writes are immediately visible.
+func (s *SqliteSearch) RefreshIndex() error {
+	return nil
+}
+
+func (s *SqliteSearch) Count(ctx context.Context) (uint64, error) {
+	var count uint64
+	if err := s.db.QueryRowxContext(ctx, `SELECT COUNT(*) FROM dialog_fts`).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func scanSqliteDocument(row interface{ Scan(dest ...any) error }) (*model.DialogDocument, error) {
+	doc := &model.DialogDocument{}
+	if err := row.Scan(
+		&doc.ID,
+		&doc.Pos,
+		&doc.MediaID,
+		&doc.Publication,
+		&doc.Series,
+		&doc.Episode,
+		&doc.StartTimestamp,
+		&doc.EndTimestamp,
+		&doc.MediaFileName,
+		&doc.Confidence,
+		&doc.Chapter,
+		&doc.Content,
+	); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -64,11 +64,15 @@ func (j *BlugeQuery) condition(field string, op searchterms.CompOp, value search
 		switch value.Type() {
 		case searchterms.IntType:
 			// is max always required?
-			q := bluge.NewNumericRangeQuery(float64(value.Value().(int64)), math.MaxFloat64)
+			q := bluge.NewNumericRangeInclusiveQuery(float64(value.Value().(int64)), math.MaxFloat64, false, true)
+			q.SetField(field)
+			return q, nil
+		case searchterms.FloatType:
+			q := bluge.NewNumericRangeInclusiveQuery(value.Value().(float64), math.MaxFloat64, false, true)
 			q.SetField(field)
 			return q, nil
 		case searchterms.DurationType:
-			q := bluge.NewNumericRangeQuery(float64(value.Value().(time.Duration).Milliseconds()), math.MaxFloat64)
+			q := bluge.NewNumericRangeInclusiveQuery(float64(value.Value().(time.Duration).Milliseconds()), math.MaxFloat64, false, true)
 			q.SetField(field)
 			return q, nil
 		case searchterms.StringType:
@@ -86,6 +90,10 @@ func (j *BlugeQuery) condition(field string, op searchterms.CompOp, value search
 			q := bluge.NewNumericRangeQuery(0-math.MaxFloat64, float64(value.Value().(int64)))
 			q.SetField(field)
 			return q, nil
+		case searchterms.FloatType:
+			q := bluge.NewNumericRangeQuery(0-math.MaxFloat64, value.Value().(float64))
+			q.SetField(field)
+			return q, nil
 		case searchterms.DurationType:
 			q := bluge.NewNumericRangeQuery(0-math.MaxFloat64, float64(value.Value().(time.Duration).Milliseconds()))
 			q.SetField(field)
@@ -105,6 +113,10 @@ func (j *BlugeQuery) condition(field string, op searchterms.CompOp, value search
 			q := bluge.NewNumericRangeInclusiveQuery(float64(value.Value().(int64)), math.MaxFloat64, true, true)
 			q.SetField(field)
 			return q, nil
+		case searchterms.FloatType:
+			q := bluge.NewNumericRangeInclusiveQuery(value.Value().(float64), math.MaxFloat64, true, true)
+			q.SetField(field)
+			return q, nil
 		case searchterms.DurationType:
 			q := bluge.NewNumericRangeInclusiveQuery(float64(value.Value().(time.Duration).Milliseconds()), math.MaxFloat64, true, true)
 			q.SetField(field)
@@ -124,6 +136,10 @@ func (j *BlugeQuery) condition(field string, op searchterms.CompOp, value search
 			q := bluge.NewNumericRangeInclusiveQuery(0-math.MaxFloat64, float64(value.Value().(int64)), true, true)
 			q.SetField(field)
 			return q, nil
+		case searchterms.FloatType:
+			q := bluge.NewNumericRangeInclusiveQuery(0-math.MaxFloat64, value.Value().(float64), true, true)
+			q.SetField(field)
+			return q, nil
 		case searchterms.DurationType:
 			q := bluge.NewNumericRangeInclusiveQuery(0-math.MaxFloat64, float64(value.Value().(time.Duration).Milliseconds()), true, true)
 			q.SetField(field)
@@ -167,6 +183,10 @@ func (j *BlugeQuery) eqFilter(field string, value searchterms.Value) (bluge.Quer
 				q := bluge.NewNumericRangeInclusiveQuery(float64(value.Value().(int64)), float64(value.Value().(int64)), true, true)
 				q.SetField(field)
 				return q, nil
+			case searchterms.FloatType:
+				q := bluge.NewNumericRangeInclusiveQuery(value.Value().(float64), value.Value().(float64), true, true)
+				q.SetField(field)
+				return q, nil
 			case searchterms.DurationType:
 				q := bluge.NewNumericRangeInclusiveQuery(float64(value.Value().(time.Duration).Milliseconds()), float64(value.Value().(time.Duration).Milliseconds()), true, true)
 				q.SetField(field)
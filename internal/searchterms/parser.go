@@ -3,11 +3,19 @@ package searchterms
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// comparison matches the fields that can be compared with a number.
+var comparison = regexp.MustCompile(`(?i)^(confidence)(<=|>=|<|>|=)([0-9]*\.?[0-9]+)$`)
+
+// fieldMatch matches the text fields that can be searched by name e.g. chapter:"monkey news". If the value is
+// quoted it will be scanned as the next token.
+var fieldMatch = regexp.MustCompile(`(?i)^(chapter):(.*)$`)
+
 type Term struct {
 	Field string
 	Value Value
@@ -82,12 +90,18 @@ func (p *parser) parseInner() ([]*Term, error) {
 			Op:    CompOpEq,
 		}}, nil
 	case tagWord:
+		if comparison, err := parseComparison(tok.lexeme); comparison != nil || err != nil {
+			return []*Term{comparison}, err
+		}
+		if match := fieldMatch.FindStringSubmatch(tok.lexeme); match != nil {
+			return p.parseFieldMatch(strings.ToLower(match[1]), match[2])
+		}
 		words := []string{tok.lexeme}
 		next, err := p.peekNext()
 		if err != nil {
 			return nil, err
 		}
-		for next.tag == tagWord {
+		for next.tag == tagWord && !isFieldTerm(next.lexeme) {
 			next, err = p.getNext()
 			if err != nil {
 				return nil, err
@@ -168,6 +182,44 @@ func (p *parser) parseInner() ([]*Term, error) {
 	}
 }
 
+// parseComparison parses a numeric field comparison e.g. confidence<0.6. Nil is returned if the word is
+// not a comparison.
+func parseComparison(word string) (*Term, error) {
+	match := comparison.FindStringSubmatch(word)
+	if match == nil {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s was not a number: %w", match[1], err)
+	}
+	return &Term{
+		Field: strings.ToLower(match[1]),
+		Value: Float(value),
+		Op:    CompOp(match[2]),
+	}, nil
+}
+
+func (p *parser) parseFieldMatch(field string, value string) ([]*Term, error) {
+	if value == "" {
+		valueText, err := p.requireNext(tagQuotedString, tagWord)
+		if err != nil {
+			return nil, err
+		}
+		value = valueText.lexeme
+	}
+	return []*Term{{
+		Field: field,
+		Value: String(value),
+		Op:    CompOpEq,
+	}}, nil
+}
+
+// isFieldTerm is true if the word should not be treated as part of a content search.
+func isFieldTerm(word string) bool {
+	return comparison.MatchString(word) || fieldMatch.MatchString(word)
+}
+
 // peekNext gets the next token without advancing.
 func (p *parser) peekNext() (token, error) {
 	if p.peeked != nil {
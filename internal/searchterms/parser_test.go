@@ -38,6 +38,29 @@ func TestMustParse(t *testing.T) {
 				{Field: "content", Value: String("baz"), Op: CompOpEq},
 			},
 		},
+		{
+			name: "parse comparison",
+			args: args{s: `foo confidence<0.6 bar`},
+			want: []Term{
+				{Field: "content", Value: String("foo"), Op: CompOpFuzzyLike},
+				{Field: "confidence", Value: Float(0.6), Op: CompOpLt},
+				{Field: "content", Value: String("bar"), Op: CompOpFuzzyLike},
+			},
+		},
+		{
+			name: "parse comparison inclusive",
+			args: args{s: `Confidence>=.5`},
+			want: []Term{{Field: "confidence", Value: Float(0.5), Op: CompOpGe}},
+		},
+		{
+			name: "parse field match",
+			args: args{s: `foo chapter:"monkey news" Chapter:fish`},
+			want: []Term{
+				{Field: "content", Value: String("foo"), Op: CompOpFuzzyLike},
+				{Field: "chapter", Value: String("monkey news"), Op: CompOpEq},
+				{Field: "chapter", Value: String("fish"), Op: CompOpEq},
+			},
+		},
 		{
 			name: "parse publication",
 			args: args{s: `~xfm`},
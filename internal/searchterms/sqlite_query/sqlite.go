@@ -0,0 +1,207 @@
+package sqlite_query
+
+import (
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/search/mapping"
+	"github.com/warmans/audio-search-bot/internal/search/model"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const ftsTable = "dialog_fts"
+
+var ftsWord = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// NewSqliteQuery creates a WHERE clause (without the WHERE keyword) and its params that can be
+// used to filter the dialog_fts table.
+func NewSqliteQuery(terms []searchterms.Term) (string, []any, *int64, error) {
+
+	// the paging/offset is included in the filter string but is not a filter so it needs to be
+	// extracted.
+	filteredTerms, offset := searchterms.ExtractOffset(terms)
+
+	q := &SqliteQuery{}
+	for _, v := range filteredTerms {
+		if err := q.And(v); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	where, params := q.Where()
+	return where, params, offset, nil
+}
+
+type SqliteQuery struct {
+	// match expressions are combined into a single MATCH since FTS5 can only use one per query.
+	match  []string
+	where  []string
+	params []any
+}
+
+func (s *SqliteQuery) And(term searchterms.Term) error {
+	return s.condition(term.Field, term.Op, term.Value)
+}
+
+// HasMatch is true if the query will filter using the full text index and can therefore be ranked.
+func (s *SqliteQuery) HasMatch() bool {
+	return len(s.match) > 0
+}
+
+func (s *SqliteQuery) Where() (string, []any) {
+	where := []string{}
+	params := []any{}
+	if len(s.match) > 0 {
+		where = append(where, fmt.Sprintf("%s MATCH ?", ftsTable))
+		params = append(params, strings.Join(s.match, " AND "))
+	}
+	where = append(where, s.where...)
+	params = append(params, s.params...)
+	// like the bluge index, a query with no terms matches nothing.
+	if len(where) == 0 {
+		return "1=0", params
+	}
+	return strings.Join(where, " AND "), params
+}
+
+func (s *SqliteQuery) condition(field string, op searchterms.CompOp, value searchterms.Value) error {
+
+	column, fieldType, err := resolveField(field)
+	if err != nil {
+		return err
+	}
+
+	switch op {
+	case searchterms.CompOpEq:
+		return s.eqFilter(column, fieldType, value)
+	case searchterms.CompOpNeq:
+		if isFullText(column) {
+			expr, err := phraseExpr(column, value)
+			if err != nil {
+				return err
+			}
+			s.where = append(s.where, fmt.Sprintf("id NOT IN (SELECT id FROM %s WHERE %s MATCH ?)", ftsTable, ftsTable))
+			s.params = append(s.params, expr)
+			return nil
+		}
+		sqlValue, err := comparableValue(fieldType, value)
+		if err != nil {
+			return err
+		}
+		s.where = append(s.where, fmt.Sprintf("%s != ?", column))
+		s.params = append(s.params, sqlValue)
+		return nil
+	case searchterms.CompOpLike, searchterms.CompOpFuzzyLike:
+		if !isFullText(column) {
+			return fmt.Errorf("field %s does not support %s operation", field, string(op))
+		}
+		expr, err := matchExpr(column, value, op == searchterms.CompOpFuzzyLike)
+		if err != nil {
+			return err
+		}
+		s.match = append(s.match, expr)
+		return nil
+	case searchterms.CompOpGt, searchterms.CompOpLt, searchterms.CompOpGe, searchterms.CompOpLe:
+		sqlValue, err := comparableValue(fieldType, value)
+		if err != nil {
+			return fmt.Errorf("value type %s is not applicable to %s operation: %w", string(value.Type()), string(op), err)
+		}
+		s.where = append(s.where, fmt.Sprintf("%s %s ?", column, string(op)))
+		s.params = append(s.params, sqlValue)
+		return nil
+	default:
+		return fmt.Errorf("operation %s was not implemented", string(op))
+	}
+}
+
+func (s *SqliteQuery) eqFilter(column string, fieldType mapping.FieldType, value searchterms.Value) error {
+	if isFullText(column) {
+		expr, err := phraseExpr(column, value)
+		if err != nil {
+			return err
+		}
+		s.match = append(s.match, expr)
+		return nil
+	}
+	sqlValue, err := comparableValue(fieldType, value)
+	if err != nil {
+		return err
+	}
+	s.where = append(s.where, fmt.Sprintf("%s = ?", column))
+	s.params = append(s.params, sqlValue)
+	return nil
+}
+
+func resolveField(field string) (string, mapping.FieldType, error) {
+	fieldMap := (&model.DialogDocument{}).FieldMapping()
+	t, ok := fieldMap[field]
+	if !ok {
+		return "", "", fmt.Errorf("unknown field %s", field)
+	}
+	if field == "_id" {
+		return "id", t, nil
+	}
+	return field, t, nil
+}
+
+func isFullText(column string) bool {
+	return column == "content" || column == "chapter"
+}
+
+func comparableValue(fieldType mapping.FieldType, value searchterms.Value) (any, error) {
+	switch fieldType {
+	case mapping.FieldTypeNumber:
+		switch value.Type() {
+		case searchterms.IntType:
+			return value.Value().(int64), nil
+		case searchterms.DurationType:
+			return value.Value().(time.Duration).Milliseconds(), nil
+		case searchterms.FloatType:
+			return value.Value().(float64), nil
+		default:
+			return nil, fmt.Errorf("cannot compare number to %s", value.Type())
+		}
+	case mapping.FieldTypeKeyword, mapping.FieldTypeText, mapping.FieldTypeShingles:
+		if value.Type() != searchterms.StringType {
+			return nil, fmt.Errorf("could not compare text field with %s", value.Type())
+		}
+		return value.Value().(string), nil
+	}
+	return nil, fmt.Errorf("unknown field type %v", fieldType)
+}
+
+// phraseExpr matches all the words in the given order.
+func phraseExpr(column string, value searchterms.Value) (string, error) {
+	words, err := ftsWords(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s : "%s"`, column, strings.Join(words, " ")), nil
+}
+
+// matchExpr matches any of the words. Since FTS5 has no fuzzy matching, prefix queries are used instead.
+func matchExpr(column string, value searchterms.Value, fuzzy bool) (string, error) {
+	words, err := ftsWords(value)
+	if err != nil {
+		return "", err
+	}
+	terms := make([]string, len(words))
+	for k, w := range words {
+		terms[k] = fmt.Sprintf(`"%s"`, w)
+		if fuzzy {
+			terms[k] += "*"
+		}
+	}
+	return fmt.Sprintf(`%s : (%s)`, column, strings.Join(terms, " OR ")), nil
+}
+
+func ftsWords(value searchterms.Value) ([]string, error) {
+	if value.Type() != searchterms.StringType {
+		return nil, fmt.Errorf("could not compare text field with %s", value.Type())
+	}
+	words := ftsWord.FindAllString(value.Value().(string), -1)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no words found in %s", value.String())
+	}
+	return words, nil
+}
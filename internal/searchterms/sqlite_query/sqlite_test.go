@@ -0,0 +1,70 @@
+package sqlite_query
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/searchterms"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"testing"
+)
+
+func TestNewSqliteQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantWhere  string
+		wantParams []any
+		wantOffset *int64
+		wantErr    require.ErrorAssertionFunc
+	}{
+		{
+			name:       "empty query matches nothing",
+			query:      "",
+			wantWhere:  "1=0",
+			wantParams: []any{},
+			wantErr:    require.NoError,
+		},
+		{
+			name:       "words are prefix matched",
+			query:      "day man",
+			wantWhere:  "dialog_fts MATCH ?",
+			wantParams: []any{`content : ("day"* OR "man"*)`},
+			wantErr:    require.NoError,
+		},
+		{
+			name:       "quoted string is phrase matched",
+			query:      `"day man"`,
+			wantWhere:  "dialog_fts MATCH ?",
+			wantParams: []any{`content : "day man"`},
+			wantErr:    require.NoError,
+		},
+		{
+			name:       "multiple matches are combined",
+			query:      `"day man" karl`,
+			wantWhere:  "dialog_fts MATCH ?",
+			wantParams: []any{`content : "day man" AND content : ("karl"*)`},
+			wantErr:    require.NoError,
+		},
+		{
+			name:       "fields are filtered",
+			query:      `~xfm #s1e2 +1m karl >10`,
+			wantWhere:  "dialog_fts MATCH ? AND publication = ? AND series = ? AND episode = ? AND start_timestamp >= ?",
+			wantParams: []any{`content : ("karl"*)`, "xfm", int64(1), int64(2), int64(60000)},
+			wantOffset: util.ToPtr(int64(10)),
+			wantErr:    require.NoError,
+		},
+		{
+			name:    "unknown fields are rejected",
+			query:   `@steve`,
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, params, offset, err := NewSqliteQuery(searchterms.MustParse(tt.query))
+			tt.wantErr(t, err)
+			require.EqualValues(t, tt.wantWhere, where)
+			require.EqualValues(t, tt.wantParams, params)
+			require.EqualValues(t, tt.wantOffset, offset)
+		})
+	}
+}
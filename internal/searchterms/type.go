@@ -4,6 +4,7 @@ type Type string
 
 const (
 	IntType      Type = "int"
+	FloatType    Type = "float"
 	StringType   Type = "string"
 	DurationType Type = "duration"
 )
@@ -50,6 +50,24 @@ func (s IntValue) String() string {
 	return fmt.Sprint(int64(s))
 }
 
+func Float(v float64) FloatValue {
+	return FloatValue(v)
+}
+
+type FloatValue float64
+
+func (s FloatValue) Type() Type {
+	return FloatType
+}
+
+func (s FloatValue) Value() interface{} {
+	return float64(s)
+}
+
+func (s FloatValue) String() string {
+	return fmt.Sprint(float64(s))
+}
+
 func Duration(ts time.Duration) DurationValue {
 	return DurationValue(ts)
 }
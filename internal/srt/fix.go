@@ -0,0 +1,105 @@
+package srt
+
+import (
+	"github.com/warmans/audio-search-bot/internal/model"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Fix merges lines shorter than the min duration into the previous line, splits lines over the max duration
+// or max chars and renumbers the result. HTML tags are already removed when the dialog is read. Words longer than
+// the max chars cannot be split and single words are limited to the max duration in the same way as Read.
+func Fix(dialog []model.Dialog, opts LintOptions) []model.Dialog {
+	merged := []model.Dialog{}
+	for _, v := range dialog {
+		if len(merged) > 0 && opts.MinDuration > 0 && v.EndTimestamp-v.StartTimestamp < opts.MinDuration {
+			prev := &merged[len(merged)-1]
+			prev.EndTimestamp = max(prev.EndTimestamp, v.EndTimestamp)
+			prev.Content = joinContent(prev.Content, v.Content)
+			continue
+		}
+		merged = append(merged, v)
+	}
+	// the first line has nothing before it so is merged into the next line instead.
+	if len(merged) > 1 && opts.MinDuration > 0 && merged[0].EndTimestamp-merged[0].StartTimestamp < opts.MinDuration {
+		merged[1].StartTimestamp = merged[0].StartTimestamp
+		merged[1].Content = joinContent(merged[0].Content, merged[1].Content)
+		merged = merged[1:]
+	}
+
+	fixed := []model.Dialog{}
+	for _, v := range merged {
+		fixed = append(fixed, split(v, opts)...)
+	}
+	for k := range fixed {
+		fixed[k].Pos = int32(k + 1)
+	}
+	return fixed
+}
+
+// split divides the line into parts that are within the limits. Words are packed into each part until it reaches
+// the character budget, which is reduced if the line must also be split to fit the max duration. The duration is
+// divided between the parts by their length.
+func split(line model.Dialog, opts LintOptions) []model.Dialog {
+	words := strings.Fields(line.Content)
+	duration := line.EndTimestamp - line.StartTimestamp
+	chars := utf8.RuneCountInString(strings.Join(words, " "))
+
+	budget := math.MaxInt
+	if opts.MaxChars > 0 && chars > opts.MaxChars {
+		budget = opts.MaxChars
+	}
+	if opts.MaxDuration > 0 && duration > opts.MaxDuration {
+		parts := int((duration + opts.MaxDuration - 1) / opts.MaxDuration)
+		budget = min(budget, (chars+parts-1)/parts)
+	}
+	if budget == math.MaxInt || len(words) <= 1 {
+		if opts.MaxDuration > 0 {
+			line.EndTimestamp = limitDuration(line.StartTimestamp, line.EndTimestamp, opts.MaxDuration)
+		}
+		return []model.Dialog{line}
+	}
+
+	parts := [][]string{}
+	partChars := []int{}
+	for _, word := range words {
+		wordChars := utf8.RuneCountInString(word)
+		if last := len(parts) - 1; last >= 0 && partChars[last]+1+wordChars <= budget {
+			parts[last] = append(parts[last], word)
+			partChars[last] += 1 + wordChars
+			continue
+		}
+		parts = append(parts, []string{word})
+		partChars = append(partChars, wordChars)
+	}
+	total := 0
+	for _, c := range partChars {
+		total += c
+	}
+
+	result := make([]model.Dialog, len(parts))
+	offset := 0
+	for k, part := range parts {
+		result[k] = line
+		result[k].StartTimestamp = line.StartTimestamp + duration*time.Duration(offset)/time.Duration(total)
+		offset += partChars[k]
+		result[k].EndTimestamp = line.StartTimestamp + duration*time.Duration(offset)/time.Duration(total)
+		if opts.MaxDuration > 0 {
+			result[k].EndTimestamp = limitDuration(result[k].StartTimestamp, result[k].EndTimestamp, opts.MaxDuration)
+		}
+		result[k].Content = strings.Join(part, " ")
+	}
+	return result
+}
+
+func joinContent(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "\n" + b
+}
@@ -0,0 +1,106 @@
+package srt
+
+import (
+	"fmt"
+	"github.com/spf13/pflag"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ProblemKind string
+
+const (
+	ProblemMalformed   = ProblemKind("malformed")
+	ProblemOverlap     = ProblemKind("overlap")
+	ProblemZeroLength  = ProblemKind("zero_length")
+	ProblemTooLong     = ProblemKind("too_long")
+	ProblemTooManyChar = ProblemKind("too_many_chars")
+	ProblemPosition    = ProblemKind("position")
+	ProblemHTML        = ProblemKind("html")
+)
+
+// Problem is an issue found by Lint. Malformed blocks have a line number, other problems refer to the position
+// of the dialog.
+type Problem struct {
+	Kind    ProblemKind
+	Line    int
+	Pos     int32
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, p.Kind, p.Message)
+	}
+	return fmt.Sprintf("pos %d: %s: %s", p.Pos, p.Kind, p.Message)
+}
+
+// LintOptions are the limits used by Lint and Fix. Zero values disable a limit.
+type LintOptions struct {
+	// MinDuration is the shortest a line can be before it is merged with the previous line by Fix.
+	MinDuration time.Duration
+	// MaxDuration is the longest a line can be. It should usually match the limitDialogDuration used by
+	// the importer, otherwise the line will be truncated.
+	MaxDuration time.Duration
+	// MaxChars is the maximum length of the line text.
+	MaxChars int
+}
+
+func DefaultLintOptions() LintOptions {
+	return LintOptions{
+		MinDuration: time.Millisecond * 500,
+		MaxDuration: time.Second * 30,
+		MaxChars:    200,
+	}
+}
+
+func (o *LintOptions) RegisterFlags(fs *pflag.FlagSet) {
+	defaults := DefaultLintOptions()
+	fs.DurationVar(&o.MinDuration, "min-duration", defaults.MinDuration, "lines shorter than this are merged with the previous line by fix (0 to disable)")
+	fs.DurationVar(&o.MaxDuration, "max-duration", defaults.MaxDuration, "max duration of a line (0 to disable)")
+	fs.IntVar(&o.MaxChars, "max-chars", defaults.MaxChars, "max characters in a line (0 to disable)")
+}
+
+// Lint reads the SRT and reports anything that would be repaired by the importer or Fix, or makes the
+// dialog harder to search.
+func Lint(source io.Reader, opts LintOptions) ([]Problem, error) {
+	dialog, warnings, err := read(source, readOptions{lenient: true, limitDialogDuration: time.Duration(math.MaxInt64), keepTags: true})
+	if err != nil {
+		return nil, err
+	}
+	problems := []Problem{}
+	for _, w := range warnings {
+		problems = append(problems, Problem{Kind: ProblemMalformed, Line: w.Line, Message: w.Message})
+	}
+	for k, v := range dialog {
+		addProblem := func(kind ProblemKind, format string, args ...any) {
+			problems = append(problems, Problem{Kind: kind, Pos: v.Pos, Message: fmt.Sprintf(format, args...)})
+		}
+		if k > 0 {
+			prev := dialog[k-1]
+			if v.Pos != prev.Pos+1 {
+				addProblem(ProblemPosition, "expected position %d", prev.Pos+1)
+			}
+			if v.StartTimestamp < prev.EndTimestamp {
+				addProblem(ProblemOverlap, "starts %s before the end of %d", prev.EndTimestamp-v.StartTimestamp, prev.Pos)
+			}
+		}
+		duration := v.EndTimestamp - v.StartTimestamp
+		if duration == 0 {
+			addProblem(ProblemZeroLength, "starts and ends at %s", FormatTimestamp(v.StartTimestamp))
+		}
+		if opts.MaxDuration > 0 && duration > opts.MaxDuration {
+			addProblem(ProblemTooLong, "duration %s exceeds %s", duration, opts.MaxDuration)
+		}
+		if chars := utf8.RuneCountInString(v.Content); opts.MaxChars > 0 && chars > opts.MaxChars {
+			addProblem(ProblemTooManyChar, "%d characters exceeds %d", chars, opts.MaxChars)
+		}
+		if tags := htmlTag.FindAllString(v.Content, -1); len(tags) > 0 {
+			addProblem(ProblemHTML, "contains %s", strings.Join(tags, ""))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,101 @@
+package srt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLint(t *testing.T) {
+	source := "1\n00:00:01,000 --> 00:00:03,000\n<i>foo</i>\n\n" +
+		"2\n00:00:02,000 --> 00:00:02,000\nbar\n\n" +
+		"4\n00:00:04,000 --> 00:00:40,000\nbaz\n\n" +
+		"x\n00:00:41 --> 00:00:42,000\nqux\n"
+
+	problems, err := Lint(strings.NewReader(source), LintOptions{MaxDuration: time.Second * 30, MaxChars: 2})
+	require.NoError(t, err)
+	require.EqualValues(t, []Problem{
+		{Kind: ProblemMalformed, Line: 13, Message: "failed to scan position: failed to parse position 'x': strconv.Atoi: parsing \"x\": invalid syntax"},
+		{Kind: ProblemMalformed, Line: 14, Message: "failed to scan timestamps: invalid start timestamp '00:00:41 ': invalid time format:00:00:41 "},
+		{Kind: ProblemTooManyChar, Pos: 1, Message: "10 characters exceeds 2"},
+		{Kind: ProblemHTML, Pos: 1, Message: "contains <i></i>"},
+		{Kind: ProblemOverlap, Pos: 2, Message: "starts 1s before the end of 1"},
+		{Kind: ProblemZeroLength, Pos: 2, Message: "starts and ends at 00:00:02,000"},
+		{Kind: ProblemTooManyChar, Pos: 2, Message: "3 characters exceeds 2"},
+		{Kind: ProblemPosition, Pos: 4, Message: "expected position 3"},
+		{Kind: ProblemTooLong, Pos: 4, Message: "duration 36s exceeds 30s"},
+		{Kind: ProblemTooManyChar, Pos: 4, Message: "3 characters exceeds 2"},
+	}, problems)
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		name   string
+		dialog []model.Dialog
+		want   []model.Dialog
+	}{
+		{
+			name: "lines are renumbered",
+			dialog: []model.Dialog{
+				{Pos: 3, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"},
+				{Pos: 7, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "bar"},
+			},
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"},
+				{Pos: 2, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "bar"},
+			},
+		},
+		{
+			name: "tiny lines are merged",
+			dialog: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Millisecond * 100, Content: "um"},
+				{Pos: 2, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"},
+				{Pos: 3, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 2, Content: "bar"},
+				{Pos: 4, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 4, Content: "baz"},
+			},
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 2, Content: "um\nfoo\nbar"},
+				{Pos: 2, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 4, Content: "baz"},
+			},
+		},
+		{
+			name: "overlong lines are split",
+			dialog: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 50, Content: "one two three\nfour five six"},
+				{Pos: 2, StartTimestamp: time.Second * 50, EndTimestamp: time.Second * 90, Content: "unsplittable"},
+			},
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 25, Content: "one two three"},
+				{Pos: 2, StartTimestamp: time.Second * 25, EndTimestamp: time.Second * 50, Content: "four five six"},
+				{Pos: 3, StartTimestamp: time.Second * 50, EndTimestamp: time.Second * 80, Content: "unsplittable"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualValues(t, tt.want, Fix(tt.dialog, DefaultLintOptions()))
+		})
+	}
+}
+
+func TestFix_lint(t *testing.T) {
+	// words of different lengths mean that an even number of words per line could exceed the max chars.
+	content := []string{}
+	for k := 0; k < 20; k++ {
+		content = append(content, "a", "supercalifragilisticexpialidocious")
+	}
+	dialog := []model.Dialog{{Pos: 1, StartTimestamp: 0, EndTimestamp: time.Second * 20, Content: strings.Join(content, " ")}}
+
+	opts := DefaultLintOptions()
+	opts.MaxChars = 50
+
+	buff := &bytes.Buffer{}
+	require.NoError(t, Write(Fix(dialog, opts), buff))
+
+	problems, err := Lint(buff, opts)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+}
@@ -13,77 +13,196 @@ import (
 	"time"
 )
 
-var htmlTag = regexp.MustCompile(`<[^<>]+>`)
-
-type srtEntity string
-
-const (
-	srtEntryPos    = srtEntity("pos")
-	srtEntryTs     = srtEntity("ts")
-	srtEntryDialog = srtEntity("dialog")
+var (
+	htmlTag = regexp.MustCompile(`<[^<>]+>`)
+	// srtTimestamp also allows a full stop as the millisecond separator. Anything after the timestamp such as
+	// position coordinates (X1:100 X2:200 Y1:10 Y2:20) is ignored.
+	srtTimestamp = regexp.MustCompile(`^\s*(\d{1,2}):(\d{2}):(\d{2})[,.](\d+)`)
 )
 
+// Read parses an SRT, failing on the first block that cannot be parsed. Blocks with only an index or with an end
+// timestamp before the start timestamp are accepted as they are.
 func Read(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error) {
+	dialog, _, err := read(source, readOptions{eliminateSpeechGaps: eliminateSpeechGaps, limitDialogDuration: limitDialogDuration})
+	return dialog, err
+}
+
+// ReadLenient is the same as Read except malformed blocks are repaired or skipped where possible. Each problem
+// is returned as a warning. Missing index lines are replaced with the next position and missing blank lines
+// between blocks are inserted.
+func ReadLenient(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, []model.ParseWarning, error) {
+	return read(source, readOptions{lenient: true, eliminateSpeechGaps: eliminateSpeechGaps, limitDialogDuration: limitDialogDuration})
+}
+
+type readOptions struct {
+	lenient             bool
+	eliminateSpeechGaps bool
+	limitDialogDuration time.Duration
+	// keepTags disables the removal of HTML tags so that they can be linted.
+	keepTags bool
+}
+
+func read(source io.Reader, opts readOptions) ([]model.Dialog, []model.ParseWarning, error) {
+	lines, err := readLines(source)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	dialog := []model.Dialog{}
-	currentDialog := model.Dialog{}
-	wantNext := srtEntryPos
+	warnings := []model.ParseWarning{}
 
-	scanner := bufio.NewScanner(source)
-	for scanner.Scan() {
-		if err := scanner.Err(); err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+	// addWarning returns an error unless the reader is lenient. k is the index of the line with the problem.
+	addWarning := func(k int, skipped bool, format string, args ...any) error {
+		warning := model.ParseWarning{Line: k + 1, Message: fmt.Sprintf(format, args...), Skipped: skipped}
+		if !opts.lenient {
+			return errors.New(warning.String())
+		}
+		warnings = append(warnings, warning)
+		return nil
+	}
+	// warn is for problems that were repaired.
+	warn := func(k int, format string, args ...any) error {
+		return addWarning(k, false, format, args...)
+	}
+	// skip is for problems that caused the block to be left out of the dialog.
+	skip := func(k int, format string, args ...any) error {
+		return addWarning(k, true, format, args...)
+	}
+	nextPos := func() int32 {
+		if len(dialog) == 0 {
+			return 1
+		}
+		return dialog[len(dialog)-1].Pos + 1
+	}
+
+	for k := 0; k < len(lines); {
+		if lines[k] == "" {
+			k++
+			continue
+		}
+		currentDialog := model.Dialog{}
+
+		if isTimestampLine(lines[k]) {
+			if err := warn(k, "missing index"); err != nil {
+				return nil, nil, err
+			}
+			currentDialog.Pos = nextPos()
+		} else {
+			pos, err := scanPos(lines[k])
+			if err != nil {
+				if k+1 >= len(lines) || !isTimestampLine(lines[k+1]) {
+					if err := skip(k, "failed to scan position: %s", err); err != nil {
+						return nil, nil, err
+					}
+					k = skipBlock(lines, k)
+					continue
+				}
+				if err := warn(k, "failed to scan position: %s", err); err != nil {
+					return nil, nil, err
+				}
+				pos = nextPos()
 			}
-			return nil, err
+			currentDialog.Pos = pos
+			k++
 		}
 
-		// strip random BOMs
-		line := strings.Replace(strings.TrimSpace(scanner.Text()), "\ufeff", "", -1)
-		if line == "" {
-			if currentDialog != (model.Dialog{}) {
+		if k >= len(lines) || lines[k] == "" {
+			// the strict reader has always accepted an index on its own as an empty line of dialog.
+			if !opts.lenient {
 				dialog = append(dialog, currentDialog)
-				currentDialog = model.Dialog{}
+				continue
 			}
-			wantNext = srtEntryPos
+			_ = skip(k-1, "missing timestamps")
 			continue
 		}
+		startTimestamp, endTimestamp, err := scanTimestamps(lines[k])
+		if err != nil {
+			if err := skip(k, "failed to scan timestamps: %s", err); err != nil {
+				return nil, nil, err
+			}
+			k = skipBlock(lines, k)
+			continue
+		}
+		// the timestamps are kept as they are since the strict reader has always accepted them.
+		if endTimestamp < startTimestamp && opts.lenient {
+			_ = warn(k, "end timestamp is before start timestamp")
+		}
+		currentDialog.StartTimestamp = startTimestamp
+		currentDialog.EndTimestamp = limitDuration(startTimestamp, endTimestamp, opts.limitDialogDuration)
+		k++
 
-		var err error
-		switch wantNext {
-		case srtEntryPos:
-			if currentDialog.Pos, err = scanPos(line); err != nil {
-				return nil, fmt.Errorf("failed to scan position: %w", err)
+		// just keep adding content until a blank line is encountered
+		for ; k < len(lines) && lines[k] != ""; k++ {
+			if opts.lenient && k+1 < len(lines) && isTimestampLine(lines[k+1]) {
+				if _, err := scanPos(lines[k]); err == nil {
+					_ = warn(k, "missing blank line before index")
+					break
+				}
 			}
-			wantNext = srtEntryTs
-		case srtEntryTs:
-			startTimesamp, endTimestamp, err := scanTimestamps(line)
-			if err != nil {
-				return nil, fmt.Errorf("failed to scan timestamps: %w", err)
+			if isTimestampLine(lines[k]) && opts.lenient {
+				_ = warn(k, "missing blank line before timestamps")
+				break
+			}
+			line := lines[k]
+			if !opts.keepTags {
+				line = htmlTag.ReplaceAllString(line, "")
 			}
-			currentDialog.StartTimestamp = startTimesamp
-			currentDialog.EndTimestamp = limitDuration(startTimesamp, endTimestamp, limitDialogDuration)
-			wantNext = srtEntryDialog
-		case srtEntryDialog:
-			line = htmlTag.ReplaceAllString(line, "")
-			// just keep adding content until a blank line is encountered
 			if currentDialog.Content == "" {
 				currentDialog.Content = line
 			} else {
 				currentDialog.Content += "\n" + line
 			}
 		}
-	}
-	if currentDialog != (model.Dialog{}) {
+		if currentDialog.Content == "" && opts.lenient {
+			_ = skip(k-1, "no dialog")
+			continue
+		}
 		dialog = append(dialog, currentDialog)
 	}
 
 	// override the end time of a line of dialog with the following line's start time
-	if eliminateSpeechGaps {
+	if opts.eliminateSpeechGaps {
 		dialog = eliminateGaps(dialog)
 	}
 
-	return dialog, nil
+	return dialog, warnings, nil
+}
+
+func readLines(source io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		// strip random BOMs
+		lines = append(lines, strings.Replace(strings.TrimSpace(scanner.Text()), "\ufeff", "", -1))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// skipBlock returns the index of the blank line following the given line.
+func skipBlock(lines []string, k int) int {
+	for k < len(lines) && lines[k] != "" {
+		k++
+	}
+	return k
+}
+
+func isTimestampLine(line string) bool {
+	return strings.Contains(line, "-->")
+}
+
+// PostProcess limits and fills gaps in dialog in the same way as Read so that other subtitle formats can be
+// imported consistently.
+func PostProcess(dialog []model.Dialog, eliminateSpeechGaps bool, limitDialogDuration time.Duration) []model.Dialog {
+	for k := range dialog {
+		dialog[k].EndTimestamp = limitDuration(dialog[k].StartTimestamp, dialog[k].EndTimestamp, limitDialogDuration)
+	}
+	if eliminateSpeechGaps {
+		dialog = eliminateGaps(dialog)
+	}
+	return dialog
 }
 
 // make the end timestamp of dialog equal to the start of the next line, unless it exceeds the max duration
@@ -134,8 +253,7 @@ func scanTimestamps(line string) (time.Duration, time.Duration, error) {
 }
 
 func parseTime(input string) (time.Duration, error) {
-	regex := regexp.MustCompile(`(\d{2}):(\d{2}):(\d{2}),(\d+)`)
-	matches := regex.FindStringSubmatch(input)
+	matches := srtTimestamp.FindStringSubmatch(input)
 
 	if len(matches) < 4 {
 		return time.Duration(0), fmt.Errorf("invalid time format:%s", input)
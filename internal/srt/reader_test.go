@@ -125,3 +125,88 @@ func TestRead(t *testing.T) {
 		})
 	}
 }
+
+func TestRead_variants(t *testing.T) {
+	want := []model.Dialog{{Pos: 1, StartTimestamp: time.Millisecond * 498, EndTimestamp: time.Second*2 + time.Millisecond*827, Content: "foo"}}
+
+	got, err := Read(strings.NewReader("1\n00:00:00.498 --> 00:00:02.827\nfoo\n"), false, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, want, got)
+
+	got, err = Read(strings.NewReader("1\n00:00:00,498 --> 00:00:02,827  X1:100 X2:200 Y1:10 Y2:20\nfoo\n"), false, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, want, got)
+
+	// these are only reported by the lenient reader.
+	got, err = Read(strings.NewReader("1\n00:00:03,000 --> 00:00:02,000\nfoo\n\n2\n\n3\n00:00:04,000 --> 00:00:05,000\nbar\n"), false, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, []model.Dialog{
+		{Pos: 1, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 2, Content: "foo"},
+		{Pos: 2},
+		{Pos: 3, StartTimestamp: time.Second * 4, EndTimestamp: time.Second * 5, Content: "bar"},
+	}, got)
+
+	_, err = Read(strings.NewReader("1\n00:00:00,498 --> 00:00:02,827\nfoo\n\nfoo\n00:00:03,000 --> 00:00:04,000\nbar\n"), false, time.Minute)
+	require.EqualError(t, err, "line 5: failed to scan position: failed to parse position 'foo': strconv.Atoi: parsing \"foo\": invalid syntax")
+}
+
+func TestReadLenient(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		want         []model.Dialog
+		wantWarnings []model.ParseWarning
+	}{
+		{
+			name:         "valid file has no warnings",
+			source:       "1\n00:00:01,000 --> 00:00:02,000\nfoo\n\n2\n00:00:02,000 --> 00:00:03,000\nbar\n",
+			want:         []model.Dialog{{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"}, {Pos: 2, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "bar"}},
+			wantWarnings: []model.ParseWarning{},
+		},
+		{
+			name:   "missing and invalid index lines are replaced",
+			source: "00:00:01,000 --> 00:00:02,000\nfoo\n\n#2\n00:00:02,000 --> 00:00:03,000\nbar\n",
+			want:   []model.Dialog{{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"}, {Pos: 2, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "bar"}},
+			wantWarnings: []model.ParseWarning{
+				{Line: 1, Message: "missing index"},
+				{Line: 4, Message: "failed to scan position: failed to parse position '#2': strconv.Atoi: parsing \"#2\": invalid syntax"},
+			},
+		},
+		{
+			name:   "bad blocks are skipped and reversed timestamps are kept",
+			source: "1\n00:00:01 --> 00:00:02,000\nfoo\n\n2\n00:00:03,000 --> 00:00:02,000\nbar\n\n3\n\n4\n00:00:04,000 --> 00:00:05,000\n\n5\n00:00:05,000 --> 00:00:06,000\nbaz\n",
+			want: []model.Dialog{
+				{Pos: 2, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 2, Content: "bar"},
+				{Pos: 5, StartTimestamp: time.Second * 5, EndTimestamp: time.Second * 6, Content: "baz"},
+			},
+			wantWarnings: []model.ParseWarning{
+				{Line: 2, Message: "failed to scan timestamps: invalid start timestamp '00:00:01 ': invalid time format:00:00:01 ", Skipped: true},
+				{Line: 6, Message: "end timestamp is before start timestamp"},
+				{Line: 9, Message: "missing timestamps", Skipped: true},
+				{Line: 12, Message: "no dialog", Skipped: true},
+			},
+		},
+		{
+			name:   "missing blank lines are inserted",
+			source: "1\n00:00:01,000 --> 00:00:02,000\nfoo\n2\n00:00:02,000 --> 00:00:03,000\nbar\n00:00:03,000 --> 00:00:04,000\nbaz\n",
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo"},
+				{Pos: 2, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "bar"},
+				{Pos: 3, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 4, Content: "baz"},
+			},
+			wantWarnings: []model.ParseWarning{
+				{Line: 4, Message: "missing blank line before index"},
+				{Line: 7, Message: "missing blank line before timestamps"},
+				{Line: 7, Message: "missing index"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := ReadLenient(strings.NewReader(tt.source), false, time.Minute)
+			require.NoError(t, err)
+			require.EqualValues(t, tt.want, got)
+			require.EqualValues(t, tt.wantWarnings, warnings)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package srt
+
+import (
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Write writes the dialog as an SRT that can be read back with Read. The dialog positions are kept. Blank lines
+// within the content are removed since they would end the block. Speakers and words are not written.
+func Write(dialog []model.Dialog, w io.Writer) error {
+	for _, v := range dialog {
+		if _, err := fmt.Fprintf(
+			w,
+			"%d\n%s --> %s\n%s\n\n",
+			v.Pos,
+			FormatTimestamp(v.StartTimestamp),
+			FormatTimestamp(v.EndTimestamp),
+			formatContent(v.Content),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the dialog to a temporary file which then replaces the output file, so a partially written
+// SRT is never imported.
+func WriteFile(dialog []model.Dialog, outputPath string) error {
+	tmpPath := outputPath + ".tmp"
+	defer os.Remove(tmpPath)
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := Write(dialog, f); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, outputPath)
+}
+
+// Backup copies the SRT to <srt>.orig unless it has already been backed up. The importer ignores the backup
+// since it has a different extension.
+func Backup(srtPath string) error {
+	backupPath := srtPath + ".orig"
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil
+	}
+	src, err := os.Open(srtPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(backupPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to backup %s: %w", srtPath, err)
+	}
+	return dst.Close()
+}
+
+// FormatTimestamp formats the duration as hh:mm:ss,mmm. Hours are not wrapped at 24.
+func FormatTimestamp(dur time.Duration) string {
+	dur = max(dur, 0)
+	return fmt.Sprintf(
+		"%02d:%02d:%02d,%03d",
+		int(dur/time.Hour),
+		int(dur/time.Minute)%60,
+		int(dur/time.Second)%60,
+		int(dur/time.Millisecond)%1000,
+	)
+}
+
+func formatContent(content string) string {
+	lines := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,30 @@
+package srt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dialog := []model.Dialog{
+		{Pos: 1, StartTimestamp: time.Millisecond * 498, EndTimestamp: time.Second*2 + time.Millisecond*827, Content: "Here's what I love most\nabout food and diet."},
+		{Pos: 3, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 4, Content: "out of order"},
+		{Pos: 4, StartTimestamp: time.Hour*25 + time.Millisecond*1, EndTimestamp: time.Hour*25 + time.Second, Content: "long"},
+	}
+	buff := &bytes.Buffer{}
+	require.NoError(t, Write(dialog, buff))
+	require.Contains(t, buff.String(), "4\n25:00:00,001 --> 25:00:01,000\nlong\n\n")
+
+	got, err := Read(bytes.NewReader(buff.Bytes()), false, time.Duration(1<<62))
+	require.NoError(t, err)
+	require.EqualValues(t, dialog, got)
+}
+
+func TestWrite_blankLines(t *testing.T) {
+	buff := &bytes.Buffer{}
+	require.NoError(t, Write([]model.Dialog{{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "foo\n\n bar"}}, buff))
+	require.EqualValues(t, "1\n00:00:01,000 --> 00:00:02,000\nfoo\nbar\n\n", buff.String())
+}
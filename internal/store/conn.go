@@ -32,7 +32,7 @@ func (c *Config) RegisterFlags(fs *pflag.FlagSet, prefix string, dbName string)
 }
 
 func NewConn(cfg *Config) (*Conn, error) {
-	db, err := sqlx.Connect("sqlite", cfg.DSN)
+	db, err := sqlx.Connect("sqlite", withForeignKeys(cfg.DSN))
 	if err != nil {
 		return nil, err
 	}
@@ -133,3 +133,15 @@ func (c *Conn) WithTx(f func(tx *sqlx.Tx) error) error {
 func (c *Conn) Close() error {
 	return c.Db.Close()
 }
+
+// withForeignKeys enables foreign key enforcement which is otherwise disabled by default
+// in sqlite and must be set for every connection.
+func withForeignKeys(dsn string) string {
+	if strings.Contains(dsn, "foreign_keys") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_pragma=foreign_keys(1)"
+	}
+	return dsn + "?_pragma=foreign_keys(1)"
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImportFailure records a file that could not be imported. The file is quarantined until NextAttempt.
+type ImportFailure struct {
+	SRTFile     string
+	Error       string
+	Attempts    int
+	LastAttempt time.Time
+	NextAttempt time.Time
+}
+
+// Quarantined is true if the file should not be retried at the given time.
+func (f ImportFailure) Quarantined(now time.Time) bool {
+	return f.NextAttempt.After(now)
+}
+
+// ImportFailureStore exposes quarantined files for admin purposes. It is implemented by SRTStore.
+type ImportFailureStore interface {
+	ListImportFailures() ([]ImportFailure, error)
+	RetryImportFailure(srtFile string) error
+}
+
+// RecordImportFailure increments the attempts for the given file and quarantines it for the duration
+// returned by backoff.
+func (s *SRTStore) RecordImportFailure(srtFile string, importErr error, backoff func(attempts int) time.Duration) (*ImportFailure, error) {
+	failure, err := s.GetImportFailure(srtFile)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		failure = &ImportFailure{SRTFile: srtFile}
+	}
+	failure.Error = importErr.Error()
+	failure.Attempts++
+	failure.LastAttempt = time.Now().UTC()
+	failure.NextAttempt = failure.LastAttempt.Add(backoff(failure.Attempts))
+
+	_, err = s.conn.Exec(
+		`
+		INSERT INTO import_failure (srt_file, error, attempts, last_attempt, next_attempt) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO UPDATE SET error=$2, attempts=$3, last_attempt=$4, next_attempt=$5
+		`,
+		failure.SRTFile,
+		failure.Error,
+		failure.Attempts,
+		failure.LastAttempt,
+		failure.NextAttempt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record import failure: %w", err)
+	}
+	return failure, nil
+}
+
+// ClearImportFailure removes the file from quarantine e.g. after it was successfully imported.
+func (s *SRTStore) ClearImportFailure(srtFile string) error {
+	_, err := s.conn.Exec(`DELETE FROM import_failure WHERE srt_file = $1`, srtFile)
+	return err
+}
+
+// RetryImportFailure makes the file eligible for import on the next sync. The attempt count is retained.
+func (s *SRTStore) RetryImportFailure(srtFile string) error {
+	res, err := s.conn.Exec(`UPDATE import_failure SET next_attempt = $1 WHERE srt_file = $2`, time.Now().UTC(), srtFile)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SRTStore) GetImportFailure(srtFile string) (*ImportFailure, error) {
+	failure, err := scanImportFailure(s.conn.QueryRowx(
+		`SELECT srt_file, error, attempts, last_attempt, next_attempt FROM import_failure WHERE srt_file = $1`,
+		srtFile,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return failure, nil
+}
+
+func (s *SRTStore) ListImportFailures() ([]ImportFailure, error) {
+	rows, err := s.conn.Queryx(`SELECT srt_file, error, attempts, last_attempt, next_attempt FROM import_failure ORDER BY srt_file`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := []ImportFailure{}
+	for rows.Next() {
+		failure, err := scanImportFailure(rows)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, *failure)
+	}
+	return failures, rows.Err()
+}
+
+func scanImportFailure(row interface{ Scan(dest ...any) error }) (*ImportFailure, error) {
+	failure := &ImportFailure{}
+	if err := row.Scan(&failure.SRTFile, &failure.Error, &failure.Attempts, &failure.LastAttempt, &failure.NextAttempt); err != nil {
+		return nil, err
+	}
+	return failure, nil
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"github.com/warmans/audio-search-bot/internal/model"
+	"sort"
+	"sync"
+)
+
+// NewMemoryStore creates a store that holds all dialog in memory. It is intended for tests
+// and embedding where a sqlite DB is not available.
+func NewMemoryStore(audio ...model.Audio) *MemoryStore {
+	s := &MemoryStore{
+		lock:     &sync.RWMutex{},
+		episodes: make(map[string]model.Episode),
+		dialog:   make(map[string][]model.Dialog),
+		manifest: make(map[string]ManifestEntry),
+	}
+	for _, v := range audio {
+		// cannot fail
+		_ = s.ImportMedia(v)
+	}
+	return s
+}
+
+type MemoryStore struct {
+	lock     *sync.RWMutex
+	episodes map[string]model.Episode
+	dialog   map[string][]model.Dialog
+	manifest map[string]ManifestEntry
+}
+
+func (s *MemoryStore) ImportMedia(m model.Audio) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dialog := make([]model.Dialog, len(m.Dialog))
+	for k, v := range m.Dialog {
+		v.MediaFileName = m.MediaFile
+		dialog[k] = v
+	}
+	sort.Slice(dialog, func(i, j int) bool {
+		return dialog[i].Pos < dialog[j].Pos
+	})
+	ep := m.ToEpisode()
+	ep.Timing = s.episodes[m.ID()].Timing
+	s.episodes[m.ID()] = ep
+	s.dialog[m.ID()] = dialog
+	return nil
+}
+
+func (s *MemoryStore) SetTimingCorrection(mediaID string, correction model.TimingCorrection) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ep, ok := s.episodes[mediaID]
+	if !ok {
+		return ErrNotFound
+	}
+	ep.Timing = correction
+	s.episodes[mediaID] = ep
+	return nil
+}
+
+func (s *MemoryStore) ListEpisodes(publication string) ([]model.Episode, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	episodes := []model.Episode{}
+	for _, v := range s.episodes {
+		if publication == "" || v.Publication == publication {
+			episodes = append(episodes, v)
+		}
+	}
+	sort.Slice(episodes, func(i, j int) bool {
+		if episodes[i].Publication != episodes[j].Publication {
+			return episodes[i].Publication < episodes[j].Publication
+		}
+		if episodes[i].Series != episodes[j].Series {
+			return episodes[i].Series < episodes[j].Series
+		}
+		return episodes[i].Episode < episodes[j].Episode
+	})
+	return episodes, nil
+}
+
+func (s *MemoryStore) GetEpisode(id string) (*model.Episode, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ep, ok := s.episodes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &ep, nil
+}
+
+func (s *MemoryStore) GetDialogRange(mediaID string, startPos int32, endPos int32) ([]model.Dialog, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	dialog := []model.Dialog{}
+	for _, v := range s.dialog[mediaID] {
+		if v.Pos >= startPos && v.Pos <= endPos {
+			dialog = append(dialog, v)
+		}
+	}
+	s.episodes[mediaID].Timing.ApplyDialog(dialog)
+	return dialog, nil
+}
+
+func (s *MemoryStore) GetDialogContext(mediaID string, startPos int32, endPos int32) ([]model.Dialog, []model.Dialog, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	before := []model.Dialog{}
+	after := []model.Dialog{}
+	for _, v := range s.dialog[mediaID] {
+		if v.Pos == startPos-1 {
+			before = append(before, v)
+		}
+		if v.Pos == endPos+1 {
+			after = append(after, v)
+		}
+	}
+	timing := s.episodes[mediaID].Timing
+	timing.ApplyDialog(before)
+	timing.ApplyDialog(after)
+	return before, after, nil
+}
+
+func (s *MemoryStore) ManifestAdd(entry ManifestEntry) (UpsertResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	original, ok := s.manifest[entry.SRTFile]
+	s.manifest[entry.SRTFile] = entry
+	if !ok {
+		return UpsertResultCreated, nil
+	}
+	if original.SameContent(entry) {
+		return UpsertResultNoop, nil
+	}
+	return UpsertResultUpdated, nil
+}
+
+func (s *MemoryStore) GetManifest() (map[string]ManifestEntry, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	manifest := make(map[string]ManifestEntry, len(s.manifest))
+	for k, v := range s.manifest {
+		manifest[k] = v
+	}
+	return manifest, nil
+}
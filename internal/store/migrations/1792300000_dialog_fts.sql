@@ -0,0 +1,35 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS "dialog_fts" USING fts5
+(
+    "id" UNINDEXED,
+    "media_id" UNINDEXED,
+    "publication" UNINDEXED,
+    "series" UNINDEXED,
+    "episode" UNINDEXED,
+    "pos" UNINDEXED,
+    "start_timestamp" UNINDEXED,
+    "end_timestamp" UNINDEXED,
+    "media_file_name" UNINDEXED,
+    "content"
+);
+
+-- backfill existing dialog. Media IDs are always in the format pub-S01E02.
+INSERT INTO dialog_fts (id, media_id, publication, series, episode, pos, start_timestamp, end_timestamp, media_file_name, content)
+WITH split AS (
+    SELECT
+        *,
+        substr(media_id, 1, instr(media_id, '-') - 1) AS pub,
+        substr(media_id, instr(media_id, '-') + 1) AS series_episode
+    FROM dialog
+)
+SELECT
+    id,
+    media_id,
+    pub,
+    CAST(substr(series_episode, 2, instr(series_episode, 'E') - 2) AS INTEGER),
+    CAST(substr(series_episode, instr(series_episode, 'E') + 1) AS INTEGER),
+    pos,
+    start_timestamp / 1000000,
+    end_timestamp / 1000000,
+    media_file_name,
+    content
+FROM split;
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS "episode"
+(
+    "id"                TEXT PRIMARY KEY,
+    "publication"       TEXT    NOT NULL,
+    "series"            INTEGER NOT NULL,
+    "episode"           INTEGER NOT NULL,
+    "media_file_name"   TEXT    NOT NULL,
+    "duration"          INTEGER NULL,
+    "artwork_file_name" TEXT    NULL,
+    "tags"              TEXT    NULL
+);
+
+CREATE INDEX episode_publication ON episode ("publication", "series", "episode");
+
+-- backfill episodes from existing dialog. Media IDs are always in the format pub-S01E02.
+INSERT INTO episode (id, publication, series, episode, media_file_name)
+WITH split AS (
+    SELECT
+        media_id,
+        MAX(media_file_name) AS media_file_name,
+        substr(media_id, 1, instr(media_id, '-') - 1) AS pub,
+        substr(media_id, instr(media_id, '-') + 1) AS series_episode
+    FROM dialog
+    GROUP BY media_id
+)
+SELECT
+    media_id,
+    pub,
+    CAST(substr(series_episode, 2, instr(series_episode, 'E') - 2) AS INTEGER),
+    CAST(substr(series_episode, instr(series_episode, 'E') + 1) AS INTEGER),
+    media_file_name
+FROM split;
+
+-- sqlite cannot add a foreign key to an existing table so the dialog table must be re-created.
+CREATE TABLE IF NOT EXISTS "dialog_new"
+(
+    "id"              TEXT PRIMARY KEY,
+    "media_id"        TEXT      NOT NULL REFERENCES episode ("id") ON DELETE CASCADE,
+    "pos"             INTEGER   NOT NULL,
+    "start_timestamp" TIMESTAMP NULL,
+    "end_timestamp"   INTEGER   NULL,
+    "content"         TEXT      NOT NULL,
+    "media_file_name" TEXT      NOT NULL
+);
+
+INSERT INTO dialog_new (id, media_id, pos, start_timestamp, end_timestamp, content, media_file_name)
+SELECT id, media_id, pos, start_timestamp, end_timestamp, content, media_file_name FROM dialog;
+
+DROP TABLE dialog;
+
+ALTER TABLE dialog_new RENAME TO dialog;
+
+CREATE INDEX dialog_pos ON dialog ("pos");
+CREATE INDEX ts ON dialog ("start_timestamp");
+CREATE INDEX dialog_media_id ON dialog ("media_id");
@@ -0,0 +1,17 @@
+-- the original unique index allowed multiple rows per file so the table must be re-created
+-- with the file name as the primary key.
+CREATE TABLE IF NOT EXISTS "manifest_new"
+(
+    "srt_file"       TEXT PRIMARY KEY,
+    "srt_mod_time"   TIMESTAMP,
+    "srt_hash"       TEXT      NULL,
+    "media_mod_time" TIMESTAMP NULL,
+    "media_hash"     TEXT      NULL
+);
+
+INSERT INTO manifest_new (srt_file, srt_mod_time)
+SELECT srt_file, MAX(srt_mod_time) FROM manifest GROUP BY srt_file;
+
+DROP TABLE manifest;
+
+ALTER TABLE manifest_new RENAME TO manifest;
@@ -0,0 +1,9 @@
+-- files that failed to import are quarantined until their next attempt is due.
+CREATE TABLE IF NOT EXISTS "import_failure"
+(
+    "srt_file"     TEXT PRIMARY KEY,
+    "error"        TEXT      NOT NULL,
+    "attempts"     INTEGER   NOT NULL DEFAULT 1,
+    "last_attempt" TIMESTAMP NOT NULL,
+    "next_attempt" TIMESTAMP NOT NULL
+);
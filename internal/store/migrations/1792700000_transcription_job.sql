@@ -0,0 +1,12 @@
+-- media files without an SRT are queued for transcription.
+CREATE TABLE IF NOT EXISTS "transcription_job"
+(
+    "media_file" TEXT PRIMARY KEY,
+    "status"     TEXT      NOT NULL,
+    "error"      TEXT      NULL,
+    "attempts"   INTEGER   NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP NOT NULL,
+    "updated_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS transcription_job_status ON transcription_job (status, created_at);
@@ -0,0 +1,12 @@
+-- word level timestamps for transcribed dialog, used to trim clips to part of a line.
+CREATE TABLE IF NOT EXISTS "dialog_word"
+(
+    "dialog_id"       TEXT    NOT NULL REFERENCES dialog ("id") ON DELETE CASCADE,
+    "pos"             INTEGER NOT NULL,
+    "text"            TEXT    NOT NULL,
+    "start_timestamp" INTEGER NOT NULL,
+    "end_timestamp"   INTEGER NOT NULL,
+    "confidence"      REAL    NOT NULL,
+    "speaker"         TEXT    NULL,
+    PRIMARY KEY ("dialog_id", "pos")
+);
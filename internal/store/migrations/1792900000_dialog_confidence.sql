@@ -0,0 +1,25 @@
+-- mean word confidence of transcribed dialog. NULL if it is not known.
+ALTER TABLE "dialog" ADD COLUMN "confidence" REAL NULL;
+
+-- FTS5 tables cannot be altered so the index is copied into a new table.
+CREATE VIRTUAL TABLE IF NOT EXISTS "dialog_fts_new" USING fts5
+(
+    "id" UNINDEXED,
+    "media_id" UNINDEXED,
+    "publication" UNINDEXED,
+    "series" UNINDEXED,
+    "episode" UNINDEXED,
+    "pos" UNINDEXED,
+    "start_timestamp" UNINDEXED,
+    "end_timestamp" UNINDEXED,
+    "media_file_name" UNINDEXED,
+    "confidence" UNINDEXED,
+    "content"
+);
+
+INSERT INTO dialog_fts_new (id, media_id, publication, series, episode, pos, start_timestamp, end_timestamp, media_file_name, confidence, content)
+SELECT id, media_id, publication, series, episode, pos, start_timestamp, end_timestamp, media_file_name, NULL, content FROM dialog_fts;
+
+DROP TABLE dialog_fts;
+
+ALTER TABLE dialog_fts_new RENAME TO dialog_fts;
@@ -0,0 +1,26 @@
+-- chapters are stored as JSON like the tags since they are always loaded with the episode.
+ALTER TABLE "episode" ADD COLUMN "chapters" TEXT NULL;
+
+-- dialog is tagged with the headline of its chapter so it can be searched.
+CREATE VIRTUAL TABLE IF NOT EXISTS "dialog_fts_new" USING fts5
+(
+    "id" UNINDEXED,
+    "media_id" UNINDEXED,
+    "publication" UNINDEXED,
+    "series" UNINDEXED,
+    "episode" UNINDEXED,
+    "pos" UNINDEXED,
+    "start_timestamp" UNINDEXED,
+    "end_timestamp" UNINDEXED,
+    "media_file_name" UNINDEXED,
+    "confidence" UNINDEXED,
+    "chapter",
+    "content"
+);
+
+INSERT INTO dialog_fts_new (id, media_id, publication, series, episode, pos, start_timestamp, end_timestamp, media_file_name, confidence, chapter, content)
+SELECT id, media_id, publication, series, episode, pos, start_timestamp, end_timestamp, media_file_name, confidence, '', content FROM dialog_fts;
+
+DROP TABLE dialog_fts;
+
+ALTER TABLE dialog_fts_new RENAME TO dialog_fts;
@@ -0,0 +1,3 @@
+-- per-episode correction of the dialog timestamps. These are not set by the import so they survive re-importing.
+ALTER TABLE "episode" ADD COLUMN "timing_offset" INTEGER NULL;
+ALTER TABLE "episode" ADD COLUMN "timing_scale" REAL NULL;
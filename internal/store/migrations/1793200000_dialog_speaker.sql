@@ -0,0 +1,2 @@
+-- speaker of the dialog if the subtitle format identifies them (e.g. WebVTT voice tags).
+ALTER TABLE "dialog" ADD COLUMN "speaker" TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- failed transcriptions are not retried until their next attempt is due.
+ALTER TABLE "transcription_job" ADD COLUMN "next_attempt" TIMESTAMP NULL;
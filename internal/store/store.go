@@ -2,7 +2,9 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/warmans/audio-search-bot/internal/model"
 	"github.com/warmans/audio-search-bot/internal/util"
@@ -16,11 +18,47 @@ const UpsertResultCreated UpsertResult = "created"
 const UpsertResultUpdated UpsertResult = "updated"
 const UpsertResultNoop UpsertResult = "noop"
 
+var ErrNotFound = errors.New("not found")
+
+type ManifestEntry struct {
+	SRTFile      string
+	SRTModTime   time.Time
+	SRTHash      string
+	MediaModTime time.Time
+	MediaHash    string
+}
+
+// Unchanged is true if neither file has been touched since the entry was recorded.
+func (m ManifestEntry) Unchanged(srtModTime time.Time, mediaModTime time.Time) bool {
+	return m.SRTModTime.Equal(srtModTime) && m.MediaModTime.Equal(mediaModTime)
+}
+
+// SameContent is true if the given entry has the same content as this entry. Entries recorded before
+// hashes were stored are considered the same if the SRT has not been touched.
+func (m ManifestEntry) SameContent(entry ManifestEntry) bool {
+	if m.SRTHash == "" {
+		return m.SRTModTime.Equal(entry.SRTModTime)
+	}
+	return m.SRTHash == entry.SRTHash && m.MediaHash == entry.MediaHash
+}
+
 type DB interface {
 	sqlx.Queryer
 	sqlx.Execer
 }
 
+// DialogStore provides access to imported dialog. It is implemented by both SRTStore and MemoryStore.
+type DialogStore interface {
+	ImportMedia(m model.Audio) error
+	GetDialogRange(mediaID string, startPos int32, endPos int32) ([]model.Dialog, error)
+	GetDialogContext(mediaID string, startPos int32, endPos int32) ([]model.Dialog, []model.Dialog, error)
+	ManifestAdd(entry ManifestEntry) (UpsertResult, error)
+	GetManifest() (map[string]ManifestEntry, error)
+	ListEpisodes(publication string) ([]model.Episode, error)
+	GetEpisode(id string) (*model.Episode, error)
+	SetTimingCorrection(mediaID string, correction model.TimingCorrection) error
+}
+
 func NewSRTStore(conn DB) *SRTStore {
 	return &SRTStore{conn: conn}
 }
@@ -30,12 +68,22 @@ type SRTStore struct {
 }
 
 func (s *SRTStore) ImportMedia(m model.Audio) error {
+	if err := s.upsertEpisode(m.ToEpisode()); err != nil {
+		return err
+	}
+	// the full text index cannot be updated in place so just clear out the old dialog.
+	if _, err := s.conn.Exec(`DELETE FROM dialog_fts WHERE media_id = $1`, m.ID()); err != nil {
+		return err
+	}
+	if _, err := s.conn.Exec(`DELETE FROM dialog_word WHERE dialog_id IN (SELECT id FROM dialog WHERE media_id = $1)`, m.ID()); err != nil {
+		return err
+	}
 	for _, v := range m.Dialog {
 		_, err := s.conn.Exec(`
 		REPLACE INTO dialog
-		    (id, media_id, pos, start_timestamp, end_timestamp, content, media_file_name) 
+		    (id, media_id, pos, start_timestamp, end_timestamp, content, media_file_name, confidence, speaker) 
 		VALUES 
-		    ($1, $2, $3, $4, $5, $6, $7)
+		    ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		`,
 			v.ID(m.ID()),
 			m.ID(),
@@ -44,17 +92,180 @@ func (s *SRTStore) ImportMedia(m model.Audio) error {
 			v.EndTimestamp,
 			v.Content,
 			m.MediaFile,
+			v.Confidence,
+			v.Speaker,
 		)
 		if err != nil {
 			return err
 		}
+		_, err = s.conn.Exec(`
+		INSERT INTO dialog_fts
+		    (id, media_id, publication, series, episode, pos, start_timestamp, end_timestamp, media_file_name, confidence, chapter, content) 
+		VALUES 
+		    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`,
+			v.ID(m.ID()),
+			m.ID(),
+			m.Publication,
+			m.Series,
+			m.Episode,
+			v.Pos,
+			v.StartTimestamp.Milliseconds(),
+			v.EndTimestamp.Milliseconds(),
+			m.MediaFile,
+			v.Confidence,
+			m.Chapters.HeadlineAt(v.StartTimestamp),
+			v.Content,
+		)
+		if err != nil {
+			return err
+		}
+		for k, w := range v.Words {
+			_, err = s.conn.Exec(`
+			INSERT INTO dialog_word
+				(dialog_id, pos, text, start_timestamp, end_timestamp, confidence, speaker)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7)
+			`,
+				v.ID(m.ID()),
+				k,
+				w.Text,
+				w.Start,
+				w.End,
+				w.Confidence,
+				w.Speaker,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SRTStore) upsertEpisode(ep model.Episode) error {
+	tags, err := json.Marshal(ep.Tags)
+	if err != nil {
+		return err
+	}
+	chapters, err := json.Marshal(ep.Chapters)
+	if err != nil {
+		return err
+	}
+	// REPLACE would cascade the delete to the dialog so an upsert must be used.
+	_, err = s.conn.Exec(`
+		INSERT INTO episode
+		    (id, publication, series, episode, media_file_name, duration, artwork_file_name, tags, chapters)
+		VALUES
+		    ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			publication=$2, series=$3, episode=$4, media_file_name=$5, duration=$6, artwork_file_name=$7, tags=$8, chapters=$9
+		`,
+		ep.ID,
+		ep.Publication,
+		ep.Series,
+		ep.Episode,
+		ep.MediaFileName,
+		ep.Duration,
+		ep.ArtworkFileName,
+		string(tags),
+		string(chapters),
+	)
+	return err
+}
+
+func (s *SRTStore) ListEpisodes(publication string) ([]model.Episode, error) {
+	rows, err := s.conn.Queryx(
+		`SELECT id, publication, series, episode, media_file_name, duration, artwork_file_name, tags, chapters, timing_offset, timing_scale FROM episode WHERE $1 = '' OR publication = $1 ORDER BY publication, series, episode`,
+		publication,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	episodes := []model.Episode{}
+	for rows.Next() {
+		ep, err := scanEpisode(rows)
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, *ep)
+	}
+	return episodes, rows.Err()
+}
+
+func (s *SRTStore) GetEpisode(id string) (*model.Episode, error) {
+	ep, err := scanEpisode(s.conn.QueryRowx(
+		`SELECT id, publication, series, episode, media_file_name, duration, artwork_file_name, tags, chapters, timing_offset, timing_scale FROM episode WHERE id = $1`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return ep, nil
+}
+
+func scanEpisode(row interface{ Scan(dest ...any) error }) (*model.Episode, error) {
+	ep := &model.Episode{}
+	var duration *time.Duration
+	var artworkFileName *string
+	var tags *string
+	var chapters *string
+	var timingOffset *time.Duration
+	var timingScale *float64
+	if err := row.Scan(&ep.ID, &ep.Publication, &ep.Series, &ep.Episode, &ep.MediaFileName, &duration, &artworkFileName, &tags, &chapters, &timingOffset, &timingScale); err != nil {
+		return nil, err
+	}
+	ep.Timing = model.TimingCorrection{Offset: util.FromPtr(timingOffset), Scale: util.FromPtr(timingScale)}
+	ep.Duration = util.FromPtr(duration)
+	ep.ArtworkFileName = util.FromPtr(artworkFileName)
+	if util.FromPtr(tags) != "" {
+		if err := json.Unmarshal([]byte(*tags), &ep.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode tags for episode %s: %w", ep.ID, err)
+		}
+	}
+	if util.FromPtr(chapters) != "" {
+		if err := json.Unmarshal([]byte(*chapters), &ep.Chapters); err != nil {
+			return nil, fmt.Errorf("failed to decode chapters for episode %s: %w", ep.ID, err)
+		}
+	}
+	return ep, nil
+}
+
+// SetTimingCorrection stores a correction that is applied to the episode's dialog when it is fetched.
+func (s *SRTStore) SetTimingCorrection(mediaID string, correction model.TimingCorrection) error {
+	res, err := s.conn.Exec(
+		`UPDATE episode SET timing_offset=$1, timing_scale=$2 WHERE id=$3`,
+		correction.Offset,
+		correction.Scale,
+		mediaID,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
 	}
 	return nil
 }
 
+func (s *SRTStore) getTimingCorrection(mediaID string) (model.TimingCorrection, error) {
+	var offset *time.Duration
+	var scale *float64
+	err := s.conn.QueryRowx(`SELECT timing_offset, timing_scale FROM episode WHERE id=$1`, mediaID).Scan(&offset, &scale)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.TimingCorrection{}, err
+	}
+	return model.TimingCorrection{Offset: util.FromPtr(offset), Scale: util.FromPtr(scale)}, nil
+}
+
 func (s *SRTStore) GetDialogRange(mediaID string, startPos int32, endPos int32) ([]model.Dialog, error) {
 	rows, err := s.conn.Queryx(
-		`SELECT pos, start_timestamp, end_timestamp, content, media_file_name  FROM "dialog" WHERE media_id=$1 AND pos >= $2 AND pos <= $3`,
+		`SELECT pos, start_timestamp, end_timestamp, content, media_file_name, confidence, speaker FROM "dialog" WHERE media_id=$1 AND pos >= $2 AND pos <= $3`,
 		mediaID,
 		startPos,
 		endPos,
@@ -72,12 +283,97 @@ func (s *SRTStore) GetDialogRange(mediaID string, startPos int32, endPos int32)
 		}
 		dialog = append(dialog, row)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.addDialogWords(mediaID, startPos, endPos, dialog); err != nil {
+		return nil, fmt.Errorf("failed to fetch words: %w", err)
+	}
+	timing, err := s.getTimingCorrection(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch timing correction: %w", err)
+	}
+	timing.ApplyDialog(dialog)
 	return dialog, nil
 }
 
+func (s *SRTStore) addDialogWords(mediaID string, startPos int32, endPos int32, dialog []model.Dialog) error {
+	if len(dialog) == 0 {
+		return nil
+	}
+	rows, err := s.conn.Queryx(
+		`
+		SELECT w.dialog_id, w.text, w.start_timestamp, w.end_timestamp, w.confidence, w.speaker
+		FROM dialog_word w
+		JOIN dialog d ON d.id = w.dialog_id
+		WHERE d.media_id=$1 AND d.pos >= $2 AND d.pos <= $3
+		ORDER BY w.dialog_id, w.pos
+		`,
+		mediaID,
+		startPos,
+		endPos,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	words := map[string][]model.TranscriptWord{}
+	for rows.Next() {
+		var dialogID string
+		var speaker *string
+		word := model.TranscriptWord{}
+		if err := rows.Scan(&dialogID, &word.Text, &word.Start, &word.End, &word.Confidence, &speaker); err != nil {
+			return err
+		}
+		word.Speaker = util.FromPtr(speaker)
+		words[dialogID] = append(words[dialogID], word)
+	}
+	for k := range dialog {
+		dialog[k].Words = words[dialog[k].ID(mediaID)]
+	}
+	return rows.Err()
+}
+
+// LowConfidenceDialog is dialog that was probably transcribed incorrectly.
+type LowConfidenceDialog struct {
+	MediaID string `db:"media_id"`
+	model.Dialog
+}
+
+// ListLowConfidenceDialog returns the dialog with a confidence below the threshold ordered by episode. Dialog with
+// an unknown confidence is never included.
+func (s *SRTStore) ListLowConfidenceDialog(publication string, threshold float64) ([]LowConfidenceDialog, error) {
+	rows, err := s.conn.Queryx(
+		`
+		SELECT d.media_id, d.pos, d.start_timestamp, d.end_timestamp, d.content, d.media_file_name, d.confidence
+		FROM dialog d
+		JOIN episode e ON e.id = d.media_id
+		WHERE ($1 = '' OR e.publication = $1) AND d.confidence < $2
+		ORDER BY e.publication, e.series, e.episode, d.pos
+		`,
+		publication,
+		threshold,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dialog := []LowConfidenceDialog{}
+	for rows.Next() {
+		row := LowConfidenceDialog{}
+		if err := rows.StructScan(&row); err != nil {
+			return nil, err
+		}
+		dialog = append(dialog, row)
+	}
+	return dialog, rows.Err()
+}
+
 func (s *SRTStore) GetDialogContext(mediaID string, startPos int32, endPos int32) ([]model.Dialog, []model.Dialog, error) {
 	rows, err := s.conn.Queryx(
-		`SELECT pos, start_timestamp, end_timestamp, content, media_file_name  FROM "dialog" WHERE media_id=$1 AND pos >= $2 AND pos <= $3`,
+		`SELECT pos, start_timestamp, end_timestamp, content, media_file_name, confidence, speaker FROM "dialog" WHERE media_id=$1 AND pos >= $2 AND pos <= $3`,
 		mediaID,
 		startPos-1,
 		endPos+1,
@@ -101,62 +397,98 @@ func (s *SRTStore) GetDialogContext(mediaID string, startPos int32, endPos int32
 			after = append(after, row)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	timing, err := s.getTimingCorrection(mediaID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch timing correction: %w", err)
+	}
+	timing.ApplyDialog(before)
+	timing.ApplyDialog(after)
 	return before, after, nil
 }
 
-func (s *SRTStore) ManifestAdd(srtFilename string, srtModTime time.Time) (UpsertResult, error) {
+// ManifestAdd records the given file and returns whether its content changed since it was last recorded.
+// Entries recorded before hashes were stored are unchanged if the SRT was not touched, and have their hashes
+// filled in.
+func (s *SRTStore) ManifestAdd(entry ManifestEntry) (UpsertResult, error) {
 
-	var originalModTime *time.Time
-	err := s.conn.QueryRowx(`SELECT srt_mod_time FROM manifest WHERE srt_file = $1`, srtFilename).Scan(&originalModTime)
+	original, err := s.getManifestEntry(entry.SRTFile)
 	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return UpsertResultNone, err
-		}
-	}
-	if originalModTime != nil {
-		if util.FromPtr(originalModTime).Equal(srtModTime) {
-			return UpsertResultNoop, nil
-		}
+		return UpsertResultNone, err
 	}
 	_, err = s.conn.Exec(
 		`
-		INSERT INTO manifest (srt_file, srt_mod_time) VALUES ($1, $2)
-		ON CONFLICT DO UPDATE SET srt_mod_time=$2
+		INSERT INTO manifest (srt_file, srt_mod_time, srt_hash, media_mod_time, media_hash) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO UPDATE SET srt_mod_time=$2, srt_hash=$3, media_mod_time=$4, media_hash=$5
 		`,
-		srtFilename,
-		srtModTime,
+		entry.SRTFile,
+		entry.SRTModTime,
+		entry.SRTHash,
+		entry.MediaModTime,
+		entry.MediaHash,
 	)
 	if err != nil {
 		return UpsertResultNone, err
 	}
-	// mod time didn't match so upsert was triggered
-	if originalModTime != nil && srtModTime.After(util.FromPtr(originalModTime)) {
-		return UpsertResultUpdated, nil
+	if original == nil {
+		return UpsertResultCreated, nil
+	}
+	if original.SameContent(entry) {
+		return UpsertResultNoop, nil
 	}
+	return UpsertResultUpdated, nil
+}
 
-	return UpsertResultCreated, nil
+func (s *SRTStore) getManifestEntry(srtFilename string) (*ManifestEntry, error) {
+	entry, err := scanManifestEntry(s.conn.QueryRowx(
+		`SELECT srt_file, srt_mod_time, srt_hash, media_mod_time, media_hash FROM manifest WHERE srt_file = $1`,
+		srtFilename,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return entry, nil
 }
 
-func (s *SRTStore) GetManifest() (map[string]time.Time, error) {
+func (s *SRTStore) GetManifest() (map[string]ManifestEntry, error) {
 
-	results, err := s.conn.Queryx(`SELECT srt_file, srt_mod_time FROM manifest`)
+	results, err := s.conn.Queryx(`SELECT srt_file, srt_mod_time, srt_hash, media_mod_time, media_hash FROM manifest`)
 	if err != nil {
 		return nil, err
 	}
 	defer results.Close()
 
-	manifest := make(map[string]time.Time)
+	manifest := make(map[string]ManifestEntry)
 	for results.Next() {
 		if err := results.Err(); err != nil {
 			return nil, err
 		}
-		var filePath string
-		var modTime *time.Time
-		if err := results.Scan(&filePath, &modTime); err != nil {
+		entry, err := scanManifestEntry(results)
+		if err != nil {
 			return nil, err
 		}
-
-		manifest[filePath] = util.FromPtr(modTime)
+		manifest[entry.SRTFile] = *entry
 	}
 	return manifest, nil
 }
+
+func scanManifestEntry(row interface{ Scan(dest ...any) error }) (*ManifestEntry, error) {
+	var srtFile string
+	var srtModTime, mediaModTime *time.Time
+	var srtHash, mediaHash *string
+	if err := row.Scan(&srtFile, &srtModTime, &srtHash, &mediaModTime, &mediaHash); err != nil {
+		return nil, err
+	}
+	return &ManifestEntry{
+		SRTFile:      srtFile,
+		SRTModTime:   util.FromPtr(srtModTime),
+		SRTHash:      util.FromPtr(srtHash),
+		MediaModTime: util.FromPtr(mediaModTime),
+		MediaHash:    util.FromPtr(mediaHash),
+	}, nil
+}
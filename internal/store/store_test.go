@@ -0,0 +1,332 @@
+package store
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"path"
+	"testing"
+	"time"
+)
+
+func newTestConn(t *testing.T) *Conn {
+	conn, err := NewConn(&Config{DSN: path.Join(t.TempDir(), "dialog.sqlite3")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+	require.NoError(t, conn.Migrate())
+	return conn
+}
+
+func TestSRTStore_Episodes(t *testing.T) {
+	s := NewSRTStore(newTestConn(t).Db)
+
+	audio := model.Audio{
+		MediaFile:   "xfm-S01E02.mp3",
+		Publication: "xfm",
+		Series:      1,
+		Episode:     2,
+		Duration:    time.Minute * 30,
+		ArtworkFile: "xfm-S01E02.png",
+		Tags:        map[string]string{"title": "Episode 2"},
+		Chapters:    model.Chapters{{Headline: "Monkey news", Summary: "Karl reads the news", Start: 0, End: time.Minute}},
+		Dialog:      []model.Dialog{{Pos: 1, Content: "foo"}, {Pos: 2, Content: "bar"}},
+	}
+	require.NoError(t, s.ImportMedia(audio))
+	require.NoError(t, s.ImportMedia(model.Audio{MediaFile: "radio-S01E01.mp3", Publication: "radio", Series: 1, Episode: 1}))
+
+	ep, err := s.GetEpisode("xfm-S01E02")
+	require.NoError(t, err)
+	require.EqualValues(t, audio.ToEpisode(), *ep)
+
+	_, err = s.GetEpisode("xfm-S09E09")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	episodes, err := s.ListEpisodes("")
+	require.NoError(t, err)
+	require.Len(t, episodes, 2)
+	require.EqualValues(t, "radio-S01E01", episodes[0].ID)
+
+	episodes, err = s.ListEpisodes("xfm")
+	require.NoError(t, err)
+	require.Len(t, episodes, 1)
+
+	// re-importing must not cascade deletes to the existing dialog
+	require.NoError(t, s.ImportMedia(audio))
+	dialog, err := s.GetDialogRange("xfm-S01E02", 1, 2)
+	require.NoError(t, err)
+	require.Len(t, dialog, 2)
+}
+
+func TestSRTStore_ManifestAdd(t *testing.T) {
+	conn := newTestConn(t)
+	s := NewSRTStore(conn.Db)
+
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := ManifestEntry{SRTFile: "xfm-S01E01.srt", SRTModTime: modTime, SRTHash: "a", MediaModTime: modTime, MediaHash: "b"}
+
+	result, err := s.ManifestAdd(entry)
+	require.NoError(t, err)
+	require.EqualValues(t, UpsertResultCreated, result)
+
+	// touching a file does not cause an update
+	touched := entry
+	touched.SRTModTime = modTime.Add(time.Hour)
+	touched.MediaModTime = modTime.Add(time.Hour)
+	result, err = s.ManifestAdd(touched)
+	require.NoError(t, err)
+	require.EqualValues(t, UpsertResultNoop, result)
+
+	manifest, err := s.GetManifest()
+	require.NoError(t, err)
+	require.True(t, manifest[entry.SRTFile].Unchanged(touched.SRTModTime, touched.MediaModTime))
+
+	// changing either file causes an update
+	srtChanged := touched
+	srtChanged.SRTHash = "c"
+	result, err = s.ManifestAdd(srtChanged)
+	require.NoError(t, err)
+	require.EqualValues(t, UpsertResultUpdated, result)
+
+	mediaChanged := srtChanged
+	mediaChanged.MediaHash = "d"
+	result, err = s.ManifestAdd(mediaChanged)
+	require.NoError(t, err)
+	require.EqualValues(t, UpsertResultUpdated, result)
+
+	// entries recorded before hashes existed are not re-imported if the mod time is the same
+	_, err = conn.Db.Exec(`INSERT INTO manifest (srt_file, srt_mod_time) VALUES ($1, $2)`, "xfm-S01E02.srt", modTime)
+	require.NoError(t, err)
+	result, err = s.ManifestAdd(ManifestEntry{SRTFile: "xfm-S01E02.srt", SRTModTime: modTime, SRTHash: "e", MediaModTime: modTime, MediaHash: "f"})
+	require.NoError(t, err)
+	require.EqualValues(t, UpsertResultNoop, result)
+}
+
+func TestSRTStore_ImportFailures(t *testing.T) {
+	s := NewSRTStore(newTestConn(t).Db)
+
+	backoff := func(attempts int) time.Duration {
+		return time.Hour * time.Duration(attempts)
+	}
+
+	failure, err := s.RecordImportFailure("xfm-S01E01.srt", errors.New("bad srt"), backoff)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, failure.Attempts)
+	require.True(t, failure.Quarantined(time.Now()))
+
+	failure, err = s.RecordImportFailure("xfm-S01E01.srt", errors.New("still bad"), backoff)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, failure.Attempts)
+	require.EqualValues(t, time.Hour*2, failure.NextAttempt.Sub(failure.LastAttempt))
+
+	failures, err := s.ListImportFailures()
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.EqualValues(t, "still bad", failures[0].Error)
+	require.EqualValues(t, 2, failures[0].Attempts)
+
+	require.NoError(t, s.RetryImportFailure("xfm-S01E01.srt"))
+	failure, err = s.GetImportFailure("xfm-S01E01.srt")
+	require.NoError(t, err)
+	require.False(t, failure.Quarantined(time.Now().Add(time.Second)))
+
+	require.ErrorIs(t, s.RetryImportFailure("xfm-S09E09.srt"), ErrNotFound)
+
+	require.NoError(t, s.ClearImportFailure("xfm-S01E01.srt"))
+	_, err = s.GetImportFailure("xfm-S01E01.srt")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSRTStore_TranscriptionJobs(t *testing.T) {
+	s := NewSRTStore(newTestConn(t).Db)
+
+	created, err := s.AddTranscriptionJob("xfm-S01E01.mp3")
+	require.NoError(t, err)
+	require.True(t, created)
+
+	created, err = s.AddTranscriptionJob("xfm-S01E01.mp3")
+	require.NoError(t, err)
+	require.False(t, created)
+
+	_, err = s.AddTranscriptionJob("xfm-S01E02.mp3")
+	require.NoError(t, err)
+
+	job, err := s.ClaimTranscriptionJob()
+	require.NoError(t, err)
+	require.EqualValues(t, "xfm-S01E01.mp3", job.MediaFile)
+	require.EqualValues(t, TranscriptionRunning, job.Status)
+	require.EqualValues(t, 1, job.Attempts)
+
+	job, err = s.ClaimTranscriptionJob()
+	require.NoError(t, err)
+	require.EqualValues(t, "xfm-S01E02.mp3", job.MediaFile)
+
+	_, err = s.ClaimTranscriptionJob()
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// interrupted jobs are returned to the queue
+	require.NoError(t, s.ResetRunningTranscriptionJobs())
+	pending, err := s.ListTranscriptionJobs(TranscriptionPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	job, err = s.ClaimTranscriptionJob()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, job.Attempts)
+	require.NoError(t, s.CompleteTranscriptionJob(job.MediaFile, errors.New("failed"), false, 0))
+
+	job, err = s.ClaimTranscriptionJob()
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteTranscriptionJob(job.MediaFile, nil, false, 0))
+
+	failed, err := s.ListTranscriptionJobs(TranscriptionFailed)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.EqualValues(t, "failed", failed[0].Error)
+
+	done, err := s.ListTranscriptionJobs(TranscriptionDone)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+
+	// failed jobs can be retried with their attempts reset.
+	require.NoError(t, s.RetryTranscriptionJob(failed[0].MediaFile))
+	require.ErrorIs(t, s.RetryTranscriptionJob(done[0].MediaFile), ErrNotFound)
+
+	job, err = s.ClaimTranscriptionJob()
+	require.NoError(t, err)
+	require.EqualValues(t, failed[0].MediaFile, job.MediaFile)
+	require.EqualValues(t, 1, job.Attempts)
+
+	// retries are not claimed until the backoff has passed.
+	require.NoError(t, s.CompleteTranscriptionJob(job.MediaFile, errors.New("rate limited"), true, time.Hour))
+	_, err = s.ClaimTranscriptionJob()
+	require.ErrorIs(t, err, ErrNotFound)
+
+	pending, err = s.ListTranscriptionJobs(TranscriptionPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.WithinDuration(t, time.Now().Add(time.Hour), pending[0].NextAttempt, time.Minute)
+
+	require.NoError(t, s.RetryTranscriptionJob(job.MediaFile))
+	job, err = s.ClaimTranscriptionJob()
+	require.NoError(t, err)
+	require.EqualValues(t, failed[0].MediaFile, job.MediaFile)
+}
+
+func TestSRTStore_DialogWords(t *testing.T) {
+	s := NewSRTStore(newTestConn(t).Db)
+
+	words := []model.TranscriptWord{
+		{Text: "foo", Start: time.Second, End: time.Second * 2, Confidence: 0.9, Speaker: "A"},
+		{Text: "bar", Start: time.Second * 2, End: time.Second * 3, Confidence: 0.5},
+	}
+	audio := model.Audio{
+		MediaFile:   "xfm-S01E02.mp3",
+		Publication: "xfm",
+		Series:      1,
+		Episode:     2,
+		Dialog: []model.Dialog{
+			{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 3, Content: "foo bar", Words: words},
+			{Pos: 2, StartTimestamp: time.Second * 3, EndTimestamp: time.Second * 4, Content: "baz", Speaker: "Karl"},
+		},
+	}
+	require.NoError(t, s.ImportMedia(audio))
+
+	dialog, err := s.GetDialogRange("xfm-S01E02", 1, 2)
+	require.NoError(t, err)
+	require.Len(t, dialog, 2)
+	require.EqualValues(t, words, dialog[0].Words)
+	require.Empty(t, dialog[1].Words)
+	require.EqualValues(t, "Karl", dialog[1].Speaker)
+
+	// re-importing replaces the words
+	audio.Dialog[0].Words = words[:1]
+	require.NoError(t, s.ImportMedia(audio))
+
+	dialog, err = s.GetDialogRange("xfm-S01E02", 1, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, words[:1], dialog[0].Words)
+}
+
+func TestSRTStore_ListLowConfidenceDialog(t *testing.T) {
+	s := NewSRTStore(newTestConn(t).Db)
+
+	for _, ep := range []model.Audio{
+		{
+			MediaFile:   "xfm-S01E02.mp3",
+			Publication: "xfm",
+			Series:      1,
+			Episode:     2,
+			Dialog: []model.Dialog{
+				{Pos: 1, Content: "foo", Confidence: util.ToPtr(0.9)},
+				{Pos: 2, Content: "bar", Confidence: util.ToPtr(0.4)},
+				{Pos: 3, Content: "baz"},
+			},
+		},
+		{
+			MediaFile:   "radio-S01E01.mp3",
+			Publication: "radio",
+			Series:      1,
+			Episode:     1,
+			Dialog: []model.Dialog{
+				{Pos: 1, Content: "qux", Confidence: util.ToPtr(0.2)},
+			},
+		},
+	} {
+		require.NoError(t, s.ImportMedia(ep))
+	}
+
+	dialog, err := s.ListLowConfidenceDialog("", 0.6)
+	require.NoError(t, err)
+	require.Len(t, dialog, 2)
+	require.EqualValues(t, "radio-S01E01", dialog[0].MediaID)
+	require.EqualValues(t, "xfm-S01E02", dialog[1].MediaID)
+	require.EqualValues(t, 0.4, *dialog[1].Confidence)
+
+	dialog, err = s.ListLowConfidenceDialog("xfm", 0.6)
+	require.NoError(t, err)
+	require.Len(t, dialog, 1)
+	require.EqualValues(t, "bar", dialog[0].Content)
+}
+
+func TestSRTStore_TimingCorrection(t *testing.T) {
+	for name, s := range map[string]DialogStore{
+		"sqlite": NewSRTStore(newTestConn(t).Db),
+		"memory": NewMemoryStore(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			audio := model.Audio{
+				MediaFile:   "xfm-S01E02.mp3",
+				Publication: "xfm",
+				Series:      1,
+				Episode:     2,
+				Dialog: []model.Dialog{
+					{Pos: 1, StartTimestamp: time.Second * 10, EndTimestamp: time.Second * 20, Content: "foo"},
+					{Pos: 2, StartTimestamp: time.Second * 20, EndTimestamp: time.Second * 30, Content: "bar"},
+				},
+			}
+			require.NoError(t, s.ImportMedia(audio))
+			require.ErrorIs(t, s.SetTimingCorrection("xfm-S09E09", model.TimingCorrection{Offset: time.Second}), ErrNotFound)
+
+			correction := model.TimingCorrection{Offset: -time.Second, Scale: 1.5}
+			require.NoError(t, s.SetTimingCorrection("xfm-S01E02", correction))
+
+			dialog, err := s.GetDialogRange("xfm-S01E02", 1, 1)
+			require.NoError(t, err)
+			require.EqualValues(t, time.Second*14, dialog[0].StartTimestamp)
+			require.EqualValues(t, time.Second*29, dialog[0].EndTimestamp)
+
+			_, after, err := s.GetDialogContext("xfm-S01E02", 1, 1)
+			require.NoError(t, err)
+			require.EqualValues(t, time.Second*29, after[0].StartTimestamp)
+
+			// re-importing keeps the correction
+			require.NoError(t, s.ImportMedia(audio))
+			ep, err := s.GetEpisode("xfm-S01E02")
+			require.NoError(t, err)
+			require.EqualValues(t, correction, ep.Timing)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/util"
+	"time"
+)
+
+type TranscriptionStatus string
+
+const (
+	TranscriptionPending TranscriptionStatus = "pending"
+	TranscriptionRunning TranscriptionStatus = "running"
+	TranscriptionDone    TranscriptionStatus = "done"
+	TranscriptionFailed  TranscriptionStatus = "failed"
+)
+
+type TranscriptionJob struct {
+	MediaFile string
+	Status    TranscriptionStatus
+	Error     string
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// NextAttempt is zero unless a failed job is waiting to be retried.
+	NextAttempt time.Time
+}
+
+// AddTranscriptionJob queues the media file for transcription. Returns false if the file already had a job.
+func (s *SRTStore) AddTranscriptionJob(mediaFile string) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.conn.Exec(
+		`INSERT INTO transcription_job (media_file, status, attempts, created_at, updated_at) VALUES ($1, $2, 0, $3, $3) ON CONFLICT DO NOTHING`,
+		mediaFile,
+		TranscriptionPending,
+		now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to add transcription job: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ClaimTranscriptionJob marks the oldest pending job as running and returns it. ErrNotFound is returned
+// if there are no pending jobs that are due.
+func (s *SRTStore) ClaimTranscriptionJob() (*TranscriptionJob, error) {
+	now := time.Now().UTC()
+	job, err := scanTranscriptionJob(s.conn.QueryRowx(
+		`
+		UPDATE transcription_job SET status=$1, attempts=attempts+1, updated_at=$2
+		WHERE media_file = (
+			SELECT media_file FROM transcription_job
+			WHERE status=$3 AND (next_attempt IS NULL OR next_attempt <= $2)
+			ORDER BY created_at, media_file LIMIT 1
+		)
+		RETURNING media_file, status, error, attempts, created_at, updated_at, next_attempt
+		`,
+		TranscriptionRunning,
+		now,
+		TranscriptionPending,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// CompleteTranscriptionJob records the result of a job. A failed job is returned to the queue if retry is true
+// but is not claimed again until retryAfter has passed.
+func (s *SRTStore) CompleteTranscriptionJob(mediaFile string, jobErr error, retry bool, retryAfter time.Duration) error {
+	now := time.Now().UTC()
+	status := TranscriptionDone
+	var errMsg *string
+	var nextAttempt *time.Time
+	if jobErr != nil {
+		status = TranscriptionFailed
+		if retry {
+			status = TranscriptionPending
+			nextAttempt = util.ToPtr(now.Add(retryAfter))
+		}
+		msg := jobErr.Error()
+		errMsg = &msg
+	}
+	_, err := s.conn.Exec(
+		`UPDATE transcription_job SET status=$1, error=$2, updated_at=$3, next_attempt=$4 WHERE media_file=$5`,
+		status,
+		errMsg,
+		now,
+		nextAttempt,
+		mediaFile,
+	)
+	return err
+}
+
+// RetryTranscriptionJob returns a failed job to the queue with its attempts reset, or makes a job that is
+// waiting to be retried due immediately.
+func (s *SRTStore) RetryTranscriptionJob(mediaFile string) error {
+	res, err := s.conn.Exec(
+		`UPDATE transcription_job SET status=$1, attempts=0, next_attempt=$2, updated_at=$2 WHERE media_file=$3 AND status IN ($4, $1)`,
+		TranscriptionPending,
+		time.Now().UTC(),
+		mediaFile,
+		TranscriptionFailed,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResetRunningTranscriptionJobs returns jobs that were interrupted (e.g. by a restart) to the queue.
+func (s *SRTStore) ResetRunningTranscriptionJobs() error {
+	_, err := s.conn.Exec(
+		`UPDATE transcription_job SET status=$1, updated_at=$2 WHERE status=$3`,
+		TranscriptionPending,
+		time.Now().UTC(),
+		TranscriptionRunning,
+	)
+	return err
+}
+
+func (s *SRTStore) ListTranscriptionJobs(status TranscriptionStatus) ([]TranscriptionJob, error) {
+	rows, err := s.conn.Queryx(
+		`SELECT media_file, status, error, attempts, created_at, updated_at, next_attempt FROM transcription_job WHERE status=$1 ORDER BY created_at, media_file`,
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []TranscriptionJob{}
+	for rows.Next() {
+		job, err := scanTranscriptionJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+func scanTranscriptionJob(row interface{ Scan(dest ...any) error }) (*TranscriptionJob, error) {
+	job := &TranscriptionJob{}
+	var jobErr *string
+	var nextAttempt *time.Time
+	if err := row.Scan(&job.MediaFile, &job.Status, &jobErr, &job.Attempts, &job.CreatedAt, &job.UpdatedAt, &nextAttempt); err != nil {
+		return nil, err
+	}
+	job.Error = util.FromPtr(jobErr)
+	job.NextAttempt = util.FromPtr(nextAttempt)
+	return job, nil
+}
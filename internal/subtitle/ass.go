@@ -0,0 +1,129 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	assTimestamp    = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})\.(\d{2})$`)
+	assOverrideTags = regexp.MustCompile(`\{[^{}]*}`)
+)
+
+// ReadASS reads Advanced SubStation Alpha (and the older SSA) subtitles. Only Dialogue events are imported and
+// the speaker is taken from the Name field.
+func ReadASS(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error) {
+
+	dialog := []model.Dialog{}
+	inEvents := false
+	var fields map[string]int
+
+	lineNum := 0
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.Replace(strings.TrimSpace(scanner.Text()), "\ufeff", "", -1)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		kind, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(kind) {
+		case "Format":
+			fields = map[string]int{}
+			for k, name := range strings.Split(value, ",") {
+				fields[strings.ToLower(strings.TrimSpace(name))] = k
+			}
+			for _, required := range []string{"start", "end", "text"} {
+				if _, ok := fields[required]; !ok {
+					return nil, fmt.Errorf("line %d: events format is missing %s", lineNum, required)
+				}
+			}
+		case "Dialogue":
+			if fields == nil {
+				return nil, fmt.Errorf("line %d: dialogue before events format", lineNum)
+			}
+			// the text is always the last field and may contain commas.
+			values := strings.SplitN(value, ",", len(fields))
+			if len(values) < len(fields) {
+				return nil, fmt.Errorf("line %d: expected %d fields but found %d", lineNum, len(fields), len(values))
+			}
+			start, err := parseASSTime(strings.TrimSpace(values[fields["start"]]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid start timestamp: %w", lineNum, err)
+			}
+			end, err := parseASSTime(strings.TrimSpace(values[fields["end"]]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid end timestamp: %w", lineNum, err)
+			}
+			current := model.Dialog{
+				StartTimestamp: start,
+				EndTimestamp:   end,
+				Content:        assText(values[fields["text"]]),
+			}
+			if name, ok := fields["name"]; ok {
+				current.Speaker = strings.TrimSpace(values[name])
+			}
+			if current.Content == "" {
+				continue
+			}
+			dialog = append(dialog, current)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// events are not required to be in order.
+	slices.SortStableFunc(dialog, func(a, b model.Dialog) int {
+		return int(a.StartTimestamp - b.StartTimestamp)
+	})
+	for k := range dialog {
+		dialog[k].Pos = int32(k + 1)
+	}
+	return srt.PostProcess(dialog, eliminateSpeechGaps, limitDialogDuration), nil
+}
+
+// assText removes override tags e.g. {\i1} and replaces the escaped line breaks and spaces.
+func assText(text string) string {
+	text = assOverrideTags.ReplaceAllString(text, "")
+	text = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(text)
+	return strings.TrimSpace(text)
+}
+
+// parseASSTime parses h:mm:ss.cc where cc is centiseconds.
+func parseASSTime(input string) (time.Duration, error) {
+	matches := assTimestamp.FindStringSubmatch(input)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid time format: %s", input)
+	}
+	parts := make([]int, 4)
+	for k, v := range matches[1:] {
+		intVal, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, err
+		}
+		parts[k] = intVal
+	}
+	return time.Duration(parts[0])*time.Hour +
+		time.Duration(parts[1])*time.Minute +
+		time.Duration(parts[2])*time.Second +
+		time.Duration(parts[3])*time.Millisecond*10, nil
+}
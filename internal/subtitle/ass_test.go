@@ -0,0 +1,65 @@
+package subtitle
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+const assHeader = "[Script Info]\nTitle: test\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n"
+
+func TestReadASS(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    []model.Dialog
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "no events returns empty result",
+			source:  assHeader,
+			want:    []model.Dialog{},
+			wantErr: require.NoError,
+		},
+		{
+			name: "events are sorted and formatting removed",
+			source: assHeader + "[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:03.50,0:00:05.00,Default,Ricky,0,0,0,,{\\i1}Well,{\\i0} no\\Nnot really\n" +
+				"Comment: 0,0:00:02.00,0:00:03.00,Default,,0,0,0,,ignored\n" +
+				"Dialogue: 0,0:00:01.00,0:00:03.50,Default,Karl,0,0,0,,Alright?\n",
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Millisecond * 3500, Content: "Alright?", Speaker: "Karl"},
+				{Pos: 2, StartTimestamp: time.Millisecond * 3500, EndTimestamp: time.Second * 5, Content: "Well, no\nnot really", Speaker: "Ricky"},
+			},
+			wantErr: require.NoError,
+		},
+		{
+			name: "SSA format without name",
+			source: "[Events]\nFormat: Marked, Start, End, Style, Text\n" +
+				"Dialogue: Marked=0,1:00:00.10,1:00:01.00,*Default,foo\\hbar\n",
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Hour + time.Millisecond*100, EndTimestamp: time.Hour + time.Second, Content: "foo bar"},
+			},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "dialogue without format is an error",
+			source:  "[Events]\nDialogue: 0,0:00:01.00,0:00:03.50,Default,Karl,0,0,0,,Alright?\n",
+			wantErr: require.Error,
+		},
+		{
+			name:    "invalid timestamp is an error",
+			source:  "[Events]\nFormat: Start, End, Text\nDialogue: 00:01.00,0:00:03.50,Alright?\n",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadASS(strings.NewReader(tt.source), false, time.Minute)
+			tt.wantErr(t, err)
+			require.EqualValues(t, tt.want, got)
+		})
+	}
+}
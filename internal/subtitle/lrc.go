@@ -0,0 +1,128 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lrcDefaultDuration is used for the last line since LRC only has start times.
+const lrcDefaultDuration = time.Second * 5
+
+var (
+	lrcTag       = regexp.MustCompile(`^\[([^\[\]]*)]`)
+	lrcTimestamp = regexp.MustCompile(`^(\d+):(\d{2})(?:[.:](\d{1,3}))?$`)
+	lrcWordTag   = regexp.MustCompile(`<\d+:\d{2}(?:[.:]\d{1,3})?>`)
+)
+
+type lrcLine struct {
+	start time.Duration
+	text  string
+}
+
+// ReadLRC reads LRC lyrics. Each line is displayed until the next one starts. Empty lines are only used to
+// end the previous line.
+func ReadLRC(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error) {
+
+	lines := []lrcLine{}
+	offset := time.Duration(0)
+
+	lineNum := 0
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.Replace(strings.TrimSpace(scanner.Text()), "\ufeff", "", -1)
+
+		// a line may have multiple timestamps if it is repeated e.g. [00:12.00][01:30.00]chorus
+		starts := []time.Duration{}
+		for {
+			tag := lrcTag.FindStringSubmatch(line)
+			if tag == nil {
+				break
+			}
+			line = strings.TrimSpace(line[len(tag[0]):])
+			if ts, ok, err := parseLRCTime(tag[1]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			} else if ok {
+				starts = append(starts, ts)
+				continue
+			}
+			// otherwise it's a metadata tag e.g. [ar:Artist] which are ignored apart from the offset.
+			key, value, _ := strings.Cut(tag[1], ":")
+			if strings.EqualFold(strings.TrimSpace(key), "offset") {
+				ms, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid offset '%s': %w", lineNum, value, err)
+				}
+				offset = time.Duration(ms) * time.Millisecond
+			}
+		}
+		text := strings.TrimSpace(lrcWordTag.ReplaceAllString(line, ""))
+		for _, start := range starts {
+			lines = append(lines, lrcLine{start: start, text: text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(lines, func(a, b lrcLine) int {
+		return int(a.start - b.start)
+	})
+
+	dialog := []model.Dialog{}
+	for k, v := range lines {
+		if v.text == "" {
+			continue
+		}
+		// a positive offset means the lyrics should be shown sooner.
+		start := max(v.start-offset, 0)
+		end := start + min(lrcDefaultDuration, limitDialogDuration)
+		if k < len(lines)-1 {
+			end = max(lines[k+1].start-offset, start)
+		}
+		dialog = append(dialog, model.Dialog{
+			Pos:            int32(len(dialog) + 1),
+			StartTimestamp: start,
+			EndTimestamp:   end,
+			Content:        v.text,
+		})
+	}
+	return srt.PostProcess(dialog, eliminateSpeechGaps, limitDialogDuration), nil
+}
+
+// parseLRCTime parses mm:ss.xx, mm:ss:xx or mm:ss. False is returned if the tag is not a timestamp.
+func parseLRCTime(input string) (time.Duration, bool, error) {
+	matches := lrcTimestamp.FindStringSubmatch(strings.TrimSpace(input))
+	if matches == nil {
+		return 0, false, nil
+	}
+	minutes, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, false, err
+	}
+	seconds, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return 0, false, err
+	}
+	fraction := time.Duration(0)
+	if matches[3] != "" {
+		intVal, err := strconv.Atoi(matches[3])
+		if err != nil {
+			return 0, false, err
+		}
+		// the fraction may be tenths, hundredths or thousandths of a second.
+		fraction = time.Duration(intVal) * time.Second
+		for range len(matches[3]) {
+			fraction /= 10
+		}
+	}
+	return time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second + fraction, true, nil
+}
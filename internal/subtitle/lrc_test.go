@@ -0,0 +1,62 @@
+package subtitle
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadLRC(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		limit   time.Duration
+		want    []model.Dialog
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "empty reader returns empty result",
+			source:  "",
+			limit:   time.Minute,
+			want:    []model.Dialog{},
+			wantErr: require.NoError,
+		},
+		{
+			name:   "lines end when the next starts",
+			source: "[ar:Someone]\n[ti:Something]\n[00:01.00]first\n[00:03.5]second\n[00:04:250]<00:04.25>third <00:05.00>line\n[00:10.00]\n",
+			limit:  time.Minute,
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Millisecond * 3500, Content: "first"},
+				{Pos: 2, StartTimestamp: time.Millisecond * 3500, EndTimestamp: time.Millisecond * 4250, Content: "second"},
+				{Pos: 3, StartTimestamp: time.Millisecond * 4250, EndTimestamp: time.Second * 10, Content: "third line"},
+			},
+			wantErr: require.NoError,
+		},
+		{
+			name:   "repeated lines and offset",
+			source: "[offset:+500]\n[00:02][00:10]chorus\n[00:05]verse\n",
+			limit:  time.Second * 3,
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Millisecond * 1500, EndTimestamp: time.Millisecond * 4500, Content: "chorus"},
+				{Pos: 2, StartTimestamp: time.Millisecond * 4500, EndTimestamp: time.Millisecond * 7500, Content: "verse"},
+				{Pos: 3, StartTimestamp: time.Millisecond * 9500, EndTimestamp: time.Millisecond * 12500, Content: "chorus"},
+			},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "invalid offset is an error",
+			source:  "[offset:soon]\n[00:02]foo\n",
+			limit:   time.Minute,
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadLRC(strings.NewReader(tt.source), false, tt.limit)
+			tt.wantErr(t, err)
+			require.EqualValues(t, tt.want, got)
+		})
+	}
+}
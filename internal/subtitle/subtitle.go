@@ -0,0 +1,89 @@
+package subtitle
+
+import (
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Reader parses a subtitle format into dialog. Implementations behave the same as srt.Read.
+type Reader interface {
+	Read(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error)
+}
+
+type ReaderFunc func(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error)
+
+func (f ReaderFunc) Read(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error) {
+	return f(source, eliminateSpeechGaps, limitDialogDuration)
+}
+
+// Extensions are in order of preference if a media file has more than one subtitle file.
+var Extensions = []string{".srt", ".vtt", ".ass", ".ssa", ".lrc"}
+
+var readers = map[string]Reader{
+	".srt": ReaderFunc(srt.Read),
+	".vtt": ReaderFunc(ReadVTT),
+	".ass": ReaderFunc(ReadASS),
+	".ssa": ReaderFunc(ReadASS),
+	".lrc": ReaderFunc(ReadLRC),
+}
+
+// ForPath returns the reader for the file extension.
+func ForPath(filePath string) (Reader, error) {
+	reader, ok := readers[strings.ToLower(path.Ext(filePath))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported subtitle format: %s", path.Ext(filePath))
+	}
+	return reader, nil
+}
+
+func IsSubtitle(filePath string) bool {
+	_, ok := readers[strings.ToLower(path.Ext(filePath))]
+	return ok
+}
+
+// Find returns the preferred subtitle file with the given path (excluding the extension) or an empty string
+// if there isn't one.
+func Find(basePath string) string {
+	for _, ext := range Extensions {
+		if _, err := os.Stat(basePath + ext); err == nil {
+			return basePath + ext
+		}
+	}
+	return ""
+}
+
+func ReadFile(filePath string, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error) {
+	reader, err := ForPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open subtitle file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	return reader.Read(f, eliminateSpeechGaps, limitDialogDuration)
+}
+
+// ReadFileLenient is the same as ReadFile except SRTs are read with srt.ReadLenient. Other formats never
+// return warnings.
+func ReadFileLenient(filePath string, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, []model.ParseWarning, error) {
+	if strings.ToLower(path.Ext(filePath)) != ".srt" {
+		dialog, err := ReadFile(filePath, eliminateSpeechGaps, limitDialogDuration)
+		return dialog, nil, err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open subtitle file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	return srt.ReadLenient(f, eliminateSpeechGaps, limitDialogDuration)
+}
@@ -0,0 +1,142 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	vttTimestamp = regexp.MustCompile(`^(?:(\d+):)?(\d{2}):(\d{2})\.(\d{3})$`)
+	vttVoice     = regexp.MustCompile(`<v(?:\.[^\s>]+)*\s+([^>]+)>`)
+	vttTag       = regexp.MustCompile(`<[^<>]+>`)
+)
+
+// ReadVTT reads WebVTT. The speaker is taken from the first voice tag (<v Speaker>) in each cue.
+func ReadVTT(source io.Reader, eliminateSpeechGaps bool, limitDialogDuration time.Duration) ([]model.Dialog, error) {
+	blocks, err := readBlocks(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0][0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+
+	dialog := []model.Dialog{}
+	for _, block := range blocks[1:] {
+		// the header is followed by optional metadata blocks.
+		if isVTTMetadata(block[0]) {
+			continue
+		}
+		tsLine := 0
+		for tsLine < len(block) && !strings.Contains(block[tsLine], "-->") {
+			tsLine++
+		}
+		if tsLine == len(block) {
+			return nil, fmt.Errorf("cue has no timestamps: '%s'", block[0])
+		}
+		start, end, err := scanVTTTimestamps(block[tsLine])
+		if err != nil {
+			return nil, err
+		}
+		text := strings.Join(block[tsLine+1:], "\n")
+		line := model.Dialog{
+			Pos:            int32(len(dialog) + 1),
+			StartTimestamp: start,
+			EndTimestamp:   end,
+			Content:        html.UnescapeString(vttTag.ReplaceAllString(text, "")),
+		}
+		if voice := vttVoice.FindStringSubmatch(text); voice != nil {
+			line.Speaker = strings.TrimSpace(voice[1])
+		}
+		dialog = append(dialog, line)
+	}
+	return srt.PostProcess(dialog, eliminateSpeechGaps, limitDialogDuration), nil
+}
+
+func isVTTMetadata(line string) bool {
+	for _, prefix := range []string{"NOTE", "STYLE", "REGION"} {
+		if line == prefix || strings.HasPrefix(line, prefix+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// scanVTTTimestamps parses the cue timing line, ignoring any cue settings e.g.
+// 00:01.000 --> 00:04.000 position:10% align:start
+func scanVTTTimestamps(line string) (time.Duration, time.Duration, error) {
+	times := strings.SplitN(line, "-->", 2)
+	startTime, err := parseVTTTime(strings.TrimSpace(times[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start timestamp '%s': %w", times[0], err)
+	}
+	if len(times) < 2 {
+		return 0, 0, fmt.Errorf("invalid timestamp line: '%s'", line)
+	}
+	fields := strings.Fields(times[1])
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("invalid end timestamp '%s': missing time", times[1])
+	}
+	endTime, err := parseVTTTime(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end timestamp '%s': %w", times[1], err)
+	}
+	return startTime, endTime, nil
+}
+
+func parseVTTTime(input string) (time.Duration, error) {
+	matches := vttTimestamp.FindStringSubmatch(input)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid time format: %s", input)
+	}
+	parts := make([]int, 4)
+	for k, v := range matches[1:] {
+		if v == "" {
+			continue
+		}
+		intVal, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, err
+		}
+		parts[k] = intVal
+	}
+	return time.Duration(parts[0])*time.Hour +
+		time.Duration(parts[1])*time.Minute +
+		time.Duration(parts[2])*time.Second +
+		time.Duration(parts[3])*time.Millisecond, nil
+}
+
+// readBlocks splits the source into groups of non-empty lines.
+func readBlocks(source io.Reader) ([][]string, error) {
+	blocks := [][]string{}
+	var block []string
+
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		// strip random BOMs
+		line := strings.Replace(strings.TrimSpace(scanner.Text()), "\ufeff", "", -1)
+		if line == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,67 @@
+package subtitle
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadVTT(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    []model.Dialog
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "missing header is an error",
+			source:  "00:01.000 --> 00:02.000\nfoo",
+			wantErr: require.Error,
+		},
+		{
+			name:    "header only returns empty result",
+			source:  "WEBVTT - some title\n",
+			want:    []model.Dialog{},
+			wantErr: require.NoError,
+		},
+		{
+			name: "cues with and without identifiers and hours",
+			source: "WEBVTT\n\nNOTE a comment\nspanning lines\n\nSTYLE\n::cue { color: red }\n\n" +
+				"intro\n00:01.000 --> 00:02.500 position:10% align:start\nHello\nthere\n\n" +
+				"01:00:02.500 --> 01:00:04.000\nGeneral Kenobi &amp; co\n",
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Millisecond * 2500, Content: "Hello\nthere"},
+				{Pos: 2, StartTimestamp: time.Hour + time.Millisecond*2500, EndTimestamp: time.Hour + time.Second*4, Content: "General Kenobi & co"},
+			},
+			wantErr: require.NoError,
+		},
+		{
+			name:   "voice tags are mapped to speaker",
+			source: "WEBVTT\n\n00:01.000 --> 00:02.000\n<v.loud Karl Pilkington>Alright?</v>\n\n00:02.000 --> 00:03.000\n<v Ricky><i>No</i>\n",
+			want: []model.Dialog{
+				{Pos: 1, StartTimestamp: time.Second, EndTimestamp: time.Second * 2, Content: "Alright?", Speaker: "Karl Pilkington"},
+				{Pos: 2, StartTimestamp: time.Second * 2, EndTimestamp: time.Second * 3, Content: "No", Speaker: "Ricky"},
+			},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "invalid timestamp is an error",
+			source:  "WEBVTT\n\n00:01,000 --> 00:02.000\nfoo\n",
+			wantErr: require.Error,
+		},
+		{
+			name:    "missing end timestamp is an error",
+			source:  "WEBVTT\n\n00:01.000 -->\nfoo\n",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadVTT(strings.NewReader(tt.source), false, time.Minute)
+			tt.wantErr(t, err)
+			require.EqualValues(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package transcriber
+
+import (
+	"fmt"
+	"github.com/spf13/pflag"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+var alignWord = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// AlignOptions control how the lines of an existing SRT are matched to a transcript of the same audio.
+type AlignOptions struct {
+	// Window is the furthest a word in the SRT may be from the same word in the transcript.
+	Window time.Duration
+	// MinMatched is the fraction of lines that must match the transcript. Fewer usually means the SRT
+	// is for different audio.
+	MinMatched float64
+}
+
+func DefaultAlignOptions() AlignOptions {
+	return AlignOptions{
+		Window:     time.Second * 30,
+		MinMatched: 0.5,
+	}
+}
+
+func (o *AlignOptions) RegisterFlags(fs *pflag.FlagSet) {
+	defaults := DefaultAlignOptions()
+	fs.DurationVar(&o.Window, "align-window", defaults.Window, "max distance between a word in the SRT and the transcript")
+	fs.Float64Var(&o.MinMatched, "align-min-matched", defaults.MinMatched, "fraction of lines that must match the transcript (0-1)")
+}
+
+// LineDrift is how far a line was moved to match the transcript.
+type LineDrift struct {
+	Pos   int32
+	Start time.Duration
+	Drift time.Duration
+	// Matched is the number of words in the line found in the transcript. Lines with no matched words are
+	// moved by the same amount as the surrounding lines.
+	Matched int
+	Words   int
+}
+
+type Alignment struct {
+	Dialog []model.Dialog
+	Lines  []LineDrift
+}
+
+func (a *Alignment) MatchedLines() int {
+	matched := 0
+	for _, v := range a.Lines {
+		if v.Matched > 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+func (a *Alignment) MatchedFraction() float64 {
+	if len(a.Lines) == 0 {
+		return 0
+	}
+	return float64(a.MatchedLines()) / float64(len(a.Lines))
+}
+
+// WriteReport summarises the drift per minute of the original SRT. Drift that increases over time usually
+// means the SRT was timed against a different frame rate.
+func (a *Alignment) WriteReport(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "Lines:        %d\nMatched:      %d (%.1f%%)\n", len(a.Lines), a.MatchedLines(), a.MatchedFraction()*100); err != nil {
+		return err
+	}
+	drift := []time.Duration{}
+	byMinute := map[time.Duration][]time.Duration{}
+	for _, v := range a.Lines {
+		if v.Matched == 0 {
+			continue
+		}
+		drift = append(drift, v.Drift)
+		byMinute[v.Start.Truncate(time.Minute)] = append(byMinute[v.Start.Truncate(time.Minute)], v.Drift)
+	}
+	if len(drift) == 0 {
+		return nil
+	}
+	maxDrift := time.Duration(0)
+	for _, v := range drift {
+		if v.Abs() > maxDrift.Abs() {
+			maxDrift = v
+		}
+	}
+	if _, err := fmt.Fprintf(w, "Median drift: %s\nMax drift:    %s\nDrift by minute:\n", formatDrift(median(drift)), formatDrift(maxDrift)); err != nil {
+		return err
+	}
+	minutes := make([]time.Duration, 0, len(byMinute))
+	for k := range byMinute {
+		minutes = append(minutes, k)
+	}
+	slices.Sort(minutes)
+	for _, minute := range minutes {
+		if _, err := fmt.Fprintf(w, "\t%s\t%s\t(%d lines)\n", srt.FormatTimestamp(minute)[:5], formatDrift(median(byMinute[minute])), len(byMinute[minute])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type alignToken struct {
+	text string
+	ts   time.Duration
+	// index is the line of the SRT or the word of the transcript.
+	index int
+}
+
+type alignPair struct {
+	srt        int
+	transcript int
+}
+
+// Align moves the SRT lines to match the transcript. Words are matched using the longest common subsequence
+// of the SRT and transcript, limited to words that are within the window of each other so that it can be
+// computed for a whole episode. Lines are re-timed using the first and last matched words where possible,
+// otherwise they are moved by the median drift of their matched words or of the surrounding lines.
+func Align(dialog []model.Dialog, transcript model.Transcript, opts AlignOptions) *Alignment {
+	srtTokens := dialogTokens(dialog)
+	transcriptTokens := transcriptTokens(transcript)
+
+	lineTokens := make([]int, len(dialog))
+	for _, v := range srtTokens {
+		lineTokens[v.index]++
+	}
+	lineMatches := make([][]alignPair, len(dialog))
+	for _, v := range longestChain(candidatePairs(srtTokens, transcriptTokens, opts.Window)) {
+		line := srtTokens[v.srt].index
+		lineMatches[line] = append(lineMatches[line], v)
+	}
+
+	result := &Alignment{Dialog: make([]model.Dialog, len(dialog)), Lines: make([]LineDrift, len(dialog))}
+	matched := []int{}
+	for k, line := range dialog {
+		result.Dialog[k] = line
+		result.Lines[k] = LineDrift{Pos: line.Pos, Start: line.StartTimestamp, Matched: len(lineMatches[k]), Words: lineTokens[k]}
+		if len(lineMatches[k]) == 0 {
+			continue
+		}
+		matched = append(matched, k)
+
+		drift := make([]time.Duration, len(lineMatches[k]))
+		for i, v := range lineMatches[k] {
+			drift[i] = transcriptTokens[v.transcript].ts - srtTokens[v.srt].ts
+		}
+		lineDrift := median(drift)
+
+		first, last := lineMatches[k][0], lineMatches[k][len(lineMatches[k])-1]
+		start, end := line.StartTimestamp+lineDrift, line.EndTimestamp+lineDrift
+		if first.srt == 0 || srtTokens[first.srt-1].index != k {
+			start = transcript.Words[transcriptTokens[first.transcript].index].Start
+		}
+		if last.srt == len(srtTokens)-1 || srtTokens[last.srt+1].index != k {
+			end = transcript.Words[transcriptTokens[last.transcript].index].End
+		}
+		result.Dialog[k].StartTimestamp, result.Dialog[k].EndTimestamp = retime(line, start, end)
+	}
+
+	// lines that could not be matched are moved in line with their neighbours.
+	for k, line := range dialog {
+		if len(lineMatches[k]) > 0 {
+			continue
+		}
+		drift := interpolateDrift(result, matched, k)
+		result.Dialog[k].StartTimestamp, result.Dialog[k].EndTimestamp = retime(line, line.StartTimestamp+drift, line.EndTimestamp+drift)
+	}
+	for k := range result.Lines {
+		result.Lines[k].Drift = result.Dialog[k].StartTimestamp - dialog[k].StartTimestamp
+	}
+	return result
+}
+
+// dialogTokens splits the lines into words. Since the SRT has no word timings the words are assumed to be
+// evenly spaced within the line.
+func dialogTokens(dialog []model.Dialog) []alignToken {
+	tokens := []alignToken{}
+	for k, line := range dialog {
+		words := alignWord.FindAllString(strings.ToLower(line.Content), -1)
+		step := (line.EndTimestamp - line.StartTimestamp) / time.Duration(max(len(words), 1))
+		for i, w := range words {
+			tokens = append(tokens, alignToken{text: w, ts: line.StartTimestamp + step*time.Duration(i), index: k})
+		}
+	}
+	return tokens
+}
+
+func transcriptTokens(transcript model.Transcript) []alignToken {
+	tokens := []alignToken{}
+	for k, word := range transcript.Words {
+		for _, w := range alignWord.FindAllString(strings.ToLower(word.Text), -1) {
+			tokens = append(tokens, alignToken{text: w, ts: word.Start, index: k})
+		}
+	}
+	return tokens
+}
+
+// candidatePairs finds the transcript words that could match each SRT word. The pairs are ordered by the SRT
+// word then by the transcript word descending which is the order required by longestChain.
+func candidatePairs(srtTokens []alignToken, transcriptTokens []alignToken, window time.Duration) []alignPair {
+	// transcript tokens are already in time order so each list is too.
+	byText := map[string][]int{}
+	for k, v := range transcriptTokens {
+		byText[v.text] = append(byText[v.text], k)
+	}
+	pairs := []alignPair{}
+	for k, v := range srtTokens {
+		candidates := byText[v.text]
+		from := sort.Search(len(candidates), func(i int) bool {
+			return transcriptTokens[candidates[i]].ts >= v.ts-window
+		})
+		to := sort.Search(len(candidates), func(i int) bool {
+			return transcriptTokens[candidates[i]].ts > v.ts+window
+		})
+		for i := to - 1; i >= from; i-- {
+			pairs = append(pairs, alignPair{srt: k, transcript: candidates[i]})
+		}
+	}
+	return pairs
+}
+
+// longestChain finds the longest sequence of pairs that are increasing in both the SRT and transcript
+// (i.e. the longest common subsequence) using patience sorting.
+func longestChain(pairs []alignPair) []alignPair {
+	if len(pairs) == 0 {
+		return nil
+	}
+	// tails[n] is the pair ending the chain of length n+1 with the earliest transcript word.
+	tails := []int{}
+	prev := make([]int, len(pairs))
+	for k, p := range pairs {
+		n := sort.Search(len(tails), func(i int) bool {
+			return pairs[tails[i]].transcript >= p.transcript
+		})
+		prev[k] = -1
+		if n > 0 {
+			prev[k] = tails[n-1]
+		}
+		if n == len(tails) {
+			tails = append(tails, k)
+		} else {
+			tails[n] = k
+		}
+	}
+	chain := make([]alignPair, len(tails))
+	for k, i := len(tails)-1, tails[len(tails)-1]; k >= 0; k, i = k-1, prev[i] {
+		chain[k] = pairs[i]
+	}
+	return chain
+}
+
+// interpolateDrift estimates the drift of an unmatched line from the nearest matched lines either side.
+func interpolateDrift(result *Alignment, matched []int, line int) time.Duration {
+	if len(matched) == 0 {
+		return 0
+	}
+	next := sort.SearchInts(matched, line)
+	if next == 0 {
+		return result.Dialog[matched[0]].StartTimestamp - result.Lines[matched[0]].Start
+	}
+	if next == len(matched) {
+		last := matched[len(matched)-1]
+		return result.Dialog[last].StartTimestamp - result.Lines[last].Start
+	}
+	before, after := matched[next-1], matched[next]
+	beforeDrift := result.Dialog[before].StartTimestamp - result.Lines[before].Start
+	afterDrift := result.Dialog[after].StartTimestamp - result.Lines[after].Start
+
+	span := result.Lines[after].Start - result.Lines[before].Start
+	if span <= 0 {
+		return beforeDrift
+	}
+	progress := float64(result.Lines[line].Start-result.Lines[before].Start) / float64(span)
+	return beforeDrift + time.Duration(float64(afterDrift-beforeDrift)*progress)
+}
+
+// retime keeps the original duration if the new timestamps are not valid.
+func retime(line model.Dialog, start time.Duration, end time.Duration) (time.Duration, time.Duration) {
+	start = max(start, 0)
+	if end <= start {
+		end = start + (line.EndTimestamp - line.StartTimestamp)
+	}
+	return start, end
+}
+
+func median(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
+
+func formatDrift(d time.Duration) string {
+	if d >= 0 {
+		return "+" + d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
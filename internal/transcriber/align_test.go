@@ -0,0 +1,65 @@
+package transcriber
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlign(t *testing.T) {
+	transcript := model.Transcript{}
+	for k, w := range strings.Fields("Hello there. How are you? Mumble. I am fine, thanks for asking.") {
+		transcript.Words = append(transcript.Words, model.TranscriptWord{
+			Text:  w,
+			Start: time.Second * time.Duration(k),
+			End:   time.Second*time.Duration(k) + time.Millisecond*800,
+		})
+	}
+
+	// the SRT is 2 seconds late and the third line was not transcribed correctly.
+	drift := time.Second * 2
+	dialog := []model.Dialog{
+		{Pos: 1, StartTimestamp: drift, EndTimestamp: drift + time.Second*2, Content: "Hello there."},
+		{Pos: 2, StartTimestamp: drift + time.Second*2, EndTimestamp: drift + time.Second*5, Content: "How are you?"},
+		{Pos: 3, StartTimestamp: drift + time.Second*5, EndTimestamp: drift + time.Second*6, Content: "(inaudible)"},
+		{Pos: 4, StartTimestamp: drift + time.Second*6, EndTimestamp: drift + time.Second*12, Content: "Eye am fine, thanks for asking."},
+	}
+
+	result := Align(dialog, transcript, DefaultAlignOptions())
+	require.EqualValues(t, 3, result.MatchedLines())
+
+	require.EqualValues(t, 0, result.Dialog[0].StartTimestamp)
+	require.EqualValues(t, time.Millisecond*1800, result.Dialog[0].EndTimestamp)
+	require.EqualValues(t, time.Second*2, result.Dialog[1].StartTimestamp)
+	require.EqualValues(t, time.Millisecond*4800, result.Dialog[1].EndTimestamp)
+
+	// the unmatched line is moved with its neighbours
+	require.EqualValues(t, time.Second*5, result.Dialog[2].StartTimestamp)
+	require.EqualValues(t, time.Second*6, result.Dialog[2].EndTimestamp)
+	require.EqualValues(t, 0, result.Lines[2].Matched)
+
+	// the first word did not match so the start is moved by the drift of the other words.
+	require.EqualValues(t, time.Second*6, result.Dialog[3].StartTimestamp)
+	require.EqualValues(t, time.Millisecond*11800, result.Dialog[3].EndTimestamp)
+	require.EqualValues(t, -drift, result.Lines[1].Drift)
+
+	report := &bytes.Buffer{}
+	require.NoError(t, result.WriteReport(report))
+	require.Contains(t, report.String(), "Median drift: -2s")
+
+	// the aligned dialog can be read back
+	srtPath := path.Join(t.TempDir(), "aligned.srt")
+	require.NoError(t, srt.WriteFile(result.Dialog, srtPath))
+	f, err := os.Open(srtPath)
+	require.NoError(t, err)
+	defer f.Close()
+	written, err := srt.Read(f, false, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, result.Dialog, written)
+}
@@ -0,0 +1,134 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/subtitle"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type BatchOptions struct {
+	// Jobs is the number of files transcribed concurrently.
+	Jobs int
+	// Retries is the number of times a file that failed with a transient error is retried.
+	Retries int
+	// RetryDelay is doubled after each attempt.
+	RetryDelay time.Duration
+	// Segment controls how the transcript is split into SRT lines.
+	Segment SegmentOptions
+}
+
+type BatchResult struct {
+	Transcribed int
+	// Cached had their SRT re-created from the transcript the backend already had, without transcribing them again.
+	Cached int
+	// Skipped already had an SRT.
+	Skipped int
+	Failed  map[string]error
+	// AudioDuration is the total duration of the audio that was transcribed.
+	AudioDuration time.Duration
+}
+
+func (r *BatchResult) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "Transcribed: %d\n", r.Transcribed)
+	fmt.Fprintf(sb, "Skipped:     %d\n", r.Skipped)
+	fmt.Fprintf(sb, "Cached:      %d\n", r.Cached)
+	fmt.Fprintf(sb, "Failed:      %d\n", len(r.Failed))
+	fmt.Fprintf(sb, "Audio:       %0.1f minutes\n", r.AudioDuration.Minutes())
+	failed := make([]string, 0, len(r.Failed))
+	for mediaPath := range r.Failed {
+		failed = append(failed, mediaPath)
+	}
+	slices.Sort(failed)
+	for _, mediaPath := range failed {
+		fmt.Fprintf(sb, "  %s: %s\n", mediaPath, r.Failed[mediaPath].Error())
+	}
+	return sb.String()
+}
+
+// Batch creates an SRT next to each of the given media files unless they already have subtitles. Media the
+// backend has already transcribed is not transcribed again, the SRT is written from the cached transcript.
+func Batch(ctx context.Context, logger *slog.Logger, transcriber Transcriber, mediaPaths []string, opts BatchOptions) *BatchResult {
+	result := &BatchResult{Failed: map[string]error{}}
+	resultLock := sync.Mutex{}
+
+	work := make(chan string)
+	go func() {
+		defer close(work)
+		for _, mediaPath := range mediaPaths {
+			select {
+			case <-ctx.Done():
+				return
+			case work <- mediaPath:
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < max(opts.Jobs, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mediaPath := range work {
+				basePath := strings.TrimSuffix(mediaPath, path.Ext(mediaPath))
+				// media with subtitles in any format is skipped, not just SRTs.
+				if subtitle.Find(basePath) != "" {
+					resultLock.Lock()
+					result.Skipped++
+					resultLock.Unlock()
+					continue
+				}
+				// cached transcripts cost nothing to write, e.g. if the SRT was deleted or a previous run was interrupted.
+				cached := IsCached(transcriber, mediaPath)
+				if cached {
+					logger.Info("Writing SRT from cached transcript...", slog.String("i", mediaPath))
+				} else {
+					logger.Info("Transcribing...", slog.String("i", mediaPath))
+				}
+				transcript, err := writeSRTWithRetry(ctx, logger, transcriber, mediaPath, basePath+".srt", opts)
+
+				resultLock.Lock()
+				if err != nil {
+					logger.Error("Transcription failed", slog.String("i", mediaPath), slog.String("err", err.Error()))
+					result.Failed[mediaPath] = err
+				} else if cached {
+					result.Cached++
+				} else {
+					result.Transcribed++
+					result.AudioDuration += transcript.Duration()
+				}
+				resultLock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
+
+func writeSRTWithRetry(ctx context.Context, logger *slog.Logger, transcriber Transcriber, mediaPath string, srtPath string, opts BatchOptions) (*model.Transcript, error) {
+	delay := opts.RetryDelay
+	for attempt := 0; ; attempt++ {
+		transcript, err := WriteSRT(ctx, transcriber, mediaPath, srtPath, opts.Segment)
+		if err == nil {
+			return transcript, nil
+		}
+		if attempt >= opts.Retries || !IsTransient(err) {
+			return nil, err
+		}
+		logger.Warn("Transcription failed, retrying...", slog.String("i", mediaPath), slog.String("err", err.Error()), slog.Duration("delay", delay))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
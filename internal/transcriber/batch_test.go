@@ -0,0 +1,103 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"log/slog"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+type temporaryError struct {
+	error
+}
+
+func (e temporaryError) Temporary() bool {
+	return true
+}
+
+// flakyTranscriber fails the given number of times for each file before succeeding. Cached files never fail
+// and are not counted as attempts since a real backend would not need to transcribe them.
+type flakyTranscriber struct {
+	*Fake
+	failures int
+	err      error
+	attempts map[string]int
+	cached   map[string]bool
+	lock     sync.Mutex
+}
+
+func newFlakyTranscriber(failures int, err error) *flakyTranscriber {
+	return &flakyTranscriber{Fake: NewFake(), failures: failures, err: err, attempts: map[string]int{}, cached: map[string]bool{}}
+}
+
+func (f *flakyTranscriber) Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error) {
+	f.lock.Lock()
+	if f.cached[mediaPath] {
+		f.lock.Unlock()
+		return f.Fake.Transcribe(ctx, mediaPath)
+	}
+	f.attempts[mediaPath]++
+	attempt := f.attempts[mediaPath]
+	f.lock.Unlock()
+	if attempt <= f.failures {
+		return nil, f.err
+	}
+	return f.Fake.Transcribe(ctx, mediaPath)
+}
+
+func (f *flakyTranscriber) Cached(mediaPath string) bool {
+	return f.cached[mediaPath]
+}
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string) string {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte("not really an mp3"), 0644))
+		return path.Join(dir, name)
+	}
+	fresh := writeFile("xfm-S01E01.mp3")
+	done := writeFile("xfm-S01E02.mp3")
+	writeFile("xfm-S01E02.srt")
+	cached := writeFile("xfm-S01E03.mp3")
+	doneVTT := writeFile("xfm-S01E05.mp3")
+	writeFile("xfm-S01E05.vtt")
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	fake := newFlakyTranscriber(1, temporaryError{errors.New("service unavailable")})
+	fake.cached[cached] = true
+
+	result := Batch(context.Background(), logger, fake, []string{fresh, done, cached, doneVTT}, BatchOptions{Jobs: 2, Retries: 1, RetryDelay: time.Millisecond, Segment: DefaultSegmentOptions()})
+	require.Empty(t, result.Failed)
+	require.EqualValues(t, 1, result.Transcribed)
+	require.EqualValues(t, 1, result.Cached)
+	require.EqualValues(t, 2, result.Skipped)
+	require.EqualValues(t, time.Second*6, result.AudioDuration)
+	require.EqualValues(t, map[string]int{fresh: 2}, fake.attempts)
+	require.FileExists(t, path.Join(dir, "xfm-S01E01.srt"))
+	require.FileExists(t, path.Join(dir, "xfm-S01E01.words.json"))
+	// the SRT is written from the cached transcript without transcribing it again.
+	require.FileExists(t, path.Join(dir, "xfm-S01E03.srt"))
+	require.NoFileExists(t, path.Join(dir, "xfm-S01E05.srt"))
+
+	// files that run out of retries are reported
+	fake = newFlakyTranscriber(3, temporaryError{errors.New("service unavailable")})
+	failing := writeFile("xfm-S01E04.mp3")
+
+	result = Batch(context.Background(), logger, fake, []string{failing}, BatchOptions{Jobs: 1, Retries: 1, RetryDelay: time.Millisecond, Segment: DefaultSegmentOptions()})
+	require.Len(t, result.Failed, 1)
+	require.EqualError(t, result.Failed[failing], "service unavailable")
+	require.NoFileExists(t, path.Join(dir, "xfm-S01E04.srt"))
+
+	// permanent errors are not retried
+	fake = newFlakyTranscriber(1, errors.New("unsupported media"))
+
+	result = Batch(context.Background(), logger, fake, []string{failing}, BatchOptions{Jobs: 1, Retries: 2, RetryDelay: time.Millisecond, Segment: DefaultSegmentOptions()})
+	require.EqualError(t, result.Failed[failing], "unsupported media")
+	require.EqualValues(t, 1, fake.attempts[failing])
+}
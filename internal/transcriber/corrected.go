@@ -0,0 +1,39 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/vocabulary"
+	"log/slog"
+)
+
+// NewCorrected applies the vocabulary spelling to the transcripts created by the given transcriber.
+func NewCorrected(logger *slog.Logger, transcriber Transcriber, vocab *vocabulary.Set) *Corrected {
+	return &Corrected{logger: logger, transcriber: transcriber, vocab: vocab}
+}
+
+type Corrected struct {
+	logger      *slog.Logger
+	transcriber Transcriber
+	vocab       *vocabulary.Set
+}
+
+func (c *Corrected) Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error) {
+	transcript, err := c.transcriber.Transcribe(ctx, mediaPath)
+	if err != nil {
+		return nil, err
+	}
+	vocab, err := c.vocab.ForMedia(mediaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vocabulary: %w", err)
+	}
+	if replaced := vocab.Correct(transcript); replaced > 0 {
+		c.logger.Info("Corrected transcript spelling", slog.String("i", mediaPath), slog.Int("replaced", replaced))
+	}
+	return transcript, nil
+}
+
+func (c *Corrected) Cached(mediaPath string) bool {
+	return IsCached(c.transcriber, mediaPath)
+}
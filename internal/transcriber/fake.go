@@ -0,0 +1,40 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultFakeText = "This is a fake transcript. It is the same for every file."
+
+// NewFake creates a transcriber that gives the same transcript for any file that exists. It is only for tests
+// so it cannot be selected as a backend.
+func NewFake() *Fake {
+	return &Fake{Text: defaultFakeText, WordDuration: time.Millisecond * 500}
+}
+
+type Fake struct {
+	Text         string
+	WordDuration time.Duration
+}
+
+func (f *Fake) Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error) {
+	if _, err := os.Stat(mediaPath); err != nil {
+		return nil, fmt.Errorf("failed to stat media: %w", err)
+	}
+	transcript := &model.Transcript{Words: []model.TranscriptWord{}}
+	for k, word := range strings.Fields(f.Text) {
+		transcript.Words = append(transcript.Words, model.TranscriptWord{
+			Text:       word,
+			Start:      f.WordDuration * time.Duration(k),
+			End:        f.WordDuration * time.Duration(k+1),
+			Confidence: 1,
+			Speaker:    "A",
+		})
+	}
+	return transcript, nil
+}
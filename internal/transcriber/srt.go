@@ -0,0 +1,122 @@
+package transcriber
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SegmentOptions control how words are grouped into SRT lines. Lines end on a sentence boundary once they
+// are at least MinDuration long, or earlier if one of the other limits is reached. Zero values disable a limit.
+type SegmentOptions struct {
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	// MaxChars is the maximum length of the line text.
+	MaxChars int
+	// SplitOnSpeakerChange starts a new line when the speaker changes, if the backend identifies speakers.
+	SplitOnSpeakerChange bool
+	// MaxGap starts a new line if there is a pause of at least this long between words.
+	MaxGap time.Duration
+}
+
+func DefaultSegmentOptions() SegmentOptions {
+	return SegmentOptions{
+		MinDuration: time.Second * 1,
+		MaxDuration: time.Second * 30,
+	}
+}
+
+func (o *SegmentOptions) RegisterFlags(fs *pflag.FlagSet) {
+	defaults := DefaultSegmentOptions()
+	fs.DurationVar(&o.MinDuration, "segment-min-duration", defaults.MinDuration, "lines shorter than this are not ended at a sentence boundary")
+	fs.DurationVar(&o.MaxDuration, "segment-max-duration", defaults.MaxDuration, "max duration of a line (0 to disable)")
+	fs.IntVar(&o.MaxChars, "segment-max-chars", defaults.MaxChars, "max characters in a line (0 to disable)")
+	fs.BoolVar(&o.SplitOnSpeakerChange, "segment-split-speaker", defaults.SplitOnSpeakerChange, "start a new line when the speaker changes")
+	fs.DurationVar(&o.MaxGap, "segment-max-gap", defaults.MaxGap, "start a new line after a pause of at least this long (0 to disable)")
+}
+
+// breakBefore is true if the word cannot be added to the line without exceeding one of the limits.
+func (o SegmentOptions) breakBefore(line []model.TranscriptWord, lineChars int, word model.TranscriptWord) bool {
+	if len(line) == 0 {
+		return false
+	}
+	prev := line[len(line)-1]
+	if o.SplitOnSpeakerChange && prev.Speaker != "" && word.Speaker != "" && prev.Speaker != word.Speaker {
+		return true
+	}
+	if o.MaxGap > 0 && word.Start-prev.End >= o.MaxGap {
+		return true
+	}
+	if o.MaxChars > 0 && lineChars+1+utf8.RuneCountInString(word.Text) > o.MaxChars {
+		return true
+	}
+	if o.MaxDuration > 0 && word.End-line[0].Start > o.MaxDuration {
+		return true
+	}
+	return false
+}
+
+// Segment groups the words into lines.
+func Segment(transcript model.Transcript, opts SegmentOptions) [][]model.TranscriptWord {
+	lines := [][]model.TranscriptWord{}
+	var line []model.TranscriptWord
+	var lineChars int
+
+	for _, word := range transcript.Words {
+		if opts.breakBefore(line, lineChars, word) {
+			lines = append(lines, line)
+			line, lineChars = nil, 0
+		}
+		if len(line) > 0 {
+			lineChars++
+		}
+		line = append(line, word)
+		lineChars += utf8.RuneCountInString(word.Text)
+
+		if isSentenceEnd(word.Text) && word.End-line[0].Start >= opts.MinDuration {
+			lines = append(lines, line)
+			line, lineChars = nil, 0
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// ToDialog groups the words into numbered lines of dialog according to the options.
+func ToDialog(transcript model.Transcript, opts SegmentOptions) []model.Dialog {
+	lines := Segment(transcript, opts)
+	dialog := make([]model.Dialog, len(lines))
+	for k, line := range lines {
+		text := make([]string, len(line))
+		for i, word := range line {
+			text[i] = word.Text
+		}
+		dialog[k] = model.Dialog{
+			Pos:            int32(k + 1),
+			StartTimestamp: line[0].Start,
+			EndTimestamp:   line[len(line)-1].End,
+			Content:        strings.Join(text, " "),
+		}
+	}
+	return dialog
+}
+
+// ToSrt writes the transcript as an SRT with the words grouped into lines according to the options.
+func ToSrt(transcript model.Transcript, outputWriter io.Writer, opts SegmentOptions) error {
+	return srt.Write(ToDialog(transcript, opts), outputWriter)
+}
+
+func isSentenceEnd(word string) bool {
+	for _, v := range []string{".", "?", "!"} {
+		if strings.HasSuffix(word, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package transcriber
+
+import (
+	"bytes"
+	"flag"
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/assemblyai"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestToSrt(t *testing.T) {
+	// testdata/transcript.mp3.json is a trimmed AssemblyAI response, converted the same way as a cached transcript.
+	transcript, err := assemblyai.LoadCached("testdata/transcript.mp3")
+	require.NoError(t, err)
+	require.NotNil(t, transcript)
+	require.Len(t, transcript.Chapters, 1)
+
+	withOpts := func(fn func(o *SegmentOptions)) SegmentOptions {
+		opts := DefaultSegmentOptions()
+		fn(&opts)
+		return opts
+	}
+
+	tests := []struct {
+		name string
+		opts SegmentOptions
+	}{
+		{name: "default", opts: DefaultSegmentOptions()},
+		{name: "max_chars", opts: withOpts(func(o *SegmentOptions) { o.MaxChars = 42 })},
+		{name: "max_duration", opts: withOpts(func(o *SegmentOptions) { o.MaxDuration = time.Second * 5 })},
+		{name: "split_speaker", opts: withOpts(func(o *SegmentOptions) { o.SplitOnSpeakerChange = true })},
+		{name: "max_gap", opts: withOpts(func(o *SegmentOptions) { o.MaxGap = time.Second })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buff := &bytes.Buffer{}
+			require.NoError(t, ToSrt(*transcript, buff, tt.opts))
+
+			goldenPath := path.Join("testdata", tt.name+".srt")
+			if *update {
+				require.NoError(t, os.WriteFile(goldenPath, buff.Bytes(), 0644))
+			}
+			golden, err := os.ReadFile(goldenPath)
+			require.NoError(t, err)
+			require.Equal(t, string(golden), buff.String())
+		})
+	}
+}
+
+func TestSegment_limits(t *testing.T) {
+	word := func(text string, start time.Duration, speaker string) model.TranscriptWord {
+		return model.TranscriptWord{Text: text, Start: start, End: start + time.Millisecond*500, Speaker: speaker}
+	}
+	transcript := model.Transcript{Words: []model.TranscriptWord{
+		word("one", 0, "A"),
+		word("two", time.Second, "A"),
+		word("three", time.Second*2, "B"),
+		word("four", time.Second*5, "B"),
+	}}
+
+	lines := Segment(transcript, SegmentOptions{SplitOnSpeakerChange: true, MaxGap: time.Second * 2})
+	require.Len(t, lines, 3)
+	require.EqualValues(t, transcript.Words[0:2], lines[0])
+	require.EqualValues(t, transcript.Words[2:3], lines[1])
+	require.EqualValues(t, transcript.Words[3:4], lines[2])
+
+	// a single word is never split even if it exceeds the limits
+	lines = Segment(transcript, SegmentOptions{MaxChars: 3})
+	require.Len(t, lines, 4)
+}
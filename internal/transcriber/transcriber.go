@@ -0,0 +1,134 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/pflag"
+	"github.com/warmans/audio-search-bot/internal/assemblyai"
+	"github.com/warmans/audio-search-bot/internal/flag"
+	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"github.com/warmans/audio-search-bot/internal/srt"
+	"github.com/warmans/audio-search-bot/internal/vocabulary"
+	"github.com/warmans/audio-search-bot/internal/whisper"
+	"log/slog"
+	"os"
+)
+
+const (
+	BackendAssemblyAI = "assemblyai"
+	BackendWhisper    = "whisper"
+)
+
+// Transcriber creates a word level transcript of the given media file.
+type Transcriber interface {
+	Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error)
+}
+
+// Cache is implemented by backends that keep the raw transcript so that media is never transcribed twice.
+type Cache interface {
+	Cached(mediaPath string) bool
+}
+
+// IsCached is false for backends without a cache.
+func IsCached(transcriber Transcriber, mediaPath string) bool {
+	if cache, ok := transcriber.(Cache); ok {
+		return cache.Cached(mediaPath)
+	}
+	return false
+}
+
+// IsTransient is true if the error may not happen again if the transcription is retried e.g. network timeouts,
+// rate limiting or server errors. Backends mark errors as transient by implementing Temporary() bool.
+func IsTransient(err error) bool {
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// Config holds the settings for all backends. The backend itself is selected separately since commands
+// name the flag differently.
+type Config struct {
+	AssemblyAIAccessToken string
+	WhisperBinary         string
+	WhisperModel          string
+}
+
+func (c *Config) RegisterFlags(fs *pflag.FlagSet, prefix string) {
+	flag.StringVarEnv(fs, &c.AssemblyAIAccessToken, prefix, "assembly-ai-access-token", "", "AssemblyAI API key (assemblyai backend)")
+	flag.StringVarEnv(fs, &c.WhisperBinary, prefix, "whisper-binary", "whisper-cli", "path to the whisper.cpp CLI (whisper backend)")
+	flag.StringVarEnv(fs, &c.WhisperModel, prefix, "whisper-model", "", "path to a whisper.cpp ggml model (whisper backend)")
+}
+
+// New creates the given backend. The vocabulary is optional, if given its spelling is applied to all transcripts.
+func New(logger *slog.Logger, backend string, cfg *Config, vocab *vocabulary.Set) (Transcriber, error) {
+	var t Transcriber
+	switch backend {
+	case BackendAssemblyAI:
+		if cfg.AssemblyAIAccessToken == "" {
+			return nil, fmt.Errorf("ASSEMBLY_AI_ACCESS_TOKEN not set")
+		}
+		t = assemblyai.NewClient(logger, cfg.AssemblyAIAccessToken, vocab)
+	case BackendWhisper:
+		if cfg.WhisperModel == "" {
+			return nil, fmt.Errorf("WHISPER_MODEL not set")
+		}
+		t = whisper.NewClient(logger, cfg.WhisperBinary, cfg.WhisperModel)
+	default:
+		return nil, fmt.Errorf("unknown transcription backend: %s", backend)
+	}
+	if vocab != nil {
+		t = NewCorrected(logger, t, vocab)
+	}
+	return t, nil
+}
+
+// WriteSRT transcribes the media and writes the transcript as an SRT. The SRT is written to a temporary file
+// first so a partial SRT is never imported. The word timings are written alongside the SRT before it is moved
+// into place so they are available when the SRT is imported.
+func WriteSRT(ctx context.Context, transcriber Transcriber, mediaPath string, outputPath string, opts SegmentOptions) (*model.Transcript, error) {
+	transcript, err := transcriber.Transcribe(ctx, mediaPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteTranscript(transcript, outputPath, opts); err != nil {
+		return nil, err
+	}
+	return transcript, nil
+}
+
+// WriteTranscript writes an existing transcript as an SRT in the same way as WriteSRT.
+func WriteTranscript(transcript *model.Transcript, outputPath string, opts SegmentOptions) error {
+	// the words are written first so that they are available when the SRT is imported.
+	if err := writeWords(transcript, metadata.WordsPath(outputPath)); err != nil {
+		return fmt.Errorf("failed to write words: %w", err)
+	}
+	if err := srt.WriteFile(ToDialog(*transcript, opts), outputPath); err != nil {
+		return fmt.Errorf("failed to write SRT: %w", err)
+	}
+	return nil
+}
+
+func writeWords(transcript *model.Transcript, outputPath string) error {
+	tmpPath := outputPath + ".tmp"
+	defer os.Remove(tmpPath)
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(transcript); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, outputPath)
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// FindFiles expands the args to a sorted list of the files that match. Each arg may be a directory which is
+// searched recursively, or a glob.
+func FindFiles(args []string, match func(filePath string) bool) ([]string, error) {
+	filePaths := []string{}
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			err := filepath.WalkDir(arg, func(filePath string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && match(filePath) {
+					filePaths = append(filePaths, filePath)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to walk %s: %w", arg, err)
+			}
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %s: %w", arg, err)
+		}
+		for _, filePath := range matches {
+			if match(filePath) {
+				filePaths = append(filePaths, filePath)
+			}
+		}
+	}
+	slices.Sort(filePaths)
+	return slices.Compact(filePaths), nil
+}
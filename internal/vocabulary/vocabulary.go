@@ -0,0 +1,241 @@
+package vocabulary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Vocabulary holds the names and phrases specific to a publication e.g.
+// {"word_boost": ["Pilkington"], "boost_param": "high", "spelling": [{"from": ["Carl"], "to": "Karl"}]}
+type Vocabulary struct {
+	// WordBoost are words or phrases the transcriber should be more likely to recognise.
+	WordBoost []string `json:"word_boost,omitempty"`
+	// BoostParam is how much to boost the words: low, default or high.
+	BoostParam string `json:"boost_param,omitempty"`
+	// Spelling replaces commonly mis-transcribed words or phrases.
+	Spelling []Spelling `json:"spelling,omitempty"`
+}
+
+type Spelling struct {
+	From []string `json:"from"`
+	To   string   `json:"to"`
+}
+
+func LoadVocabulary(filePath string) (*Vocabulary, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	vocab := &Vocabulary{}
+	if err := json.NewDecoder(f).Decode(vocab); err != nil {
+		return nil, fmt.Errorf("failed to decode vocabulary %s: %w", filePath, err)
+	}
+	for _, v := range vocab.Spelling {
+		if strings.TrimSpace(v.To) == "" || len(v.From) == 0 {
+			return nil, fmt.Errorf("vocabulary %s has a spelling without from or to", filePath)
+		}
+	}
+	return vocab, nil
+}
+
+// Correct applies the spelling to the transcript and returns the number of replacements. Matching ignores
+// case and punctuation and the punctuation around the original words is kept.
+func (v *Vocabulary) Correct(transcript *model.Transcript) int {
+	if v == nil {
+		return 0
+	}
+	replaced := 0
+	for _, spelling := range v.Spelling {
+		to := strings.Fields(spelling.To)
+		for _, from := range spelling.From {
+			find := normaliseWords(strings.Fields(from))
+			if len(find) == 0 || equalWords(find, normaliseWords(to)) {
+				continue
+			}
+			for k := 0; k <= len(transcript.Words)-len(find); k++ {
+				if !matchAt(transcript.Words, k, find) || alreadyCorrected(transcript.Words, k, find, normaliseWords(to)) {
+					continue
+				}
+				replacement := replaceWords(transcript.Words[k:k+len(find)], to)
+				transcript.Words = append(transcript.Words[:k], append(replacement, transcript.Words[k+len(find):]...)...)
+				k += len(replacement) - 1
+				replaced++
+			}
+		}
+	}
+	return replaced
+}
+
+func matchAt(words []model.TranscriptWord, pos int, find []string) bool {
+	for k, w := range find {
+		if normaliseWord(words[pos+k].Text) != w {
+			return false
+		}
+	}
+	return true
+}
+
+// alreadyCorrected is true if the words found at pos are part of the replacement e.g. "Pilkington" when
+// correcting "pilkington" to "Karl Pilkington". Without this correcting a transcript twice would not be a noop.
+func alreadyCorrected(words []model.TranscriptWord, pos int, find []string, to []string) bool {
+	for offset := 0; offset <= len(to)-len(find); offset++ {
+		if !equalWords(find, to[offset:offset+len(find)]) {
+			continue
+		}
+		start := pos - offset
+		if start >= 0 && start+len(to) <= len(words) && matchAt(words, start, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceWords spreads the replacement over the time taken by the original words.
+func replaceWords(original []model.TranscriptWord, to []string) []model.TranscriptWord {
+	first, last := original[0], original[len(original)-1]
+	prefix := leadingPunctuation(first.Text)
+	suffix := trailingPunctuation(last.Text)
+
+	confidence := first.Confidence
+	for _, w := range original {
+		confidence = min(confidence, w.Confidence)
+	}
+
+	step := (last.End - first.Start) / time.Duration(len(to))
+	replacement := make([]model.TranscriptWord, len(to))
+	for k, text := range to {
+		if k == 0 {
+			text = prefix + text
+		}
+		if k == len(to)-1 {
+			text = text + suffix
+		}
+		replacement[k] = model.TranscriptWord{
+			Text:       text,
+			Start:      first.Start + step*time.Duration(k),
+			End:        first.Start + step*time.Duration(k+1),
+			Confidence: confidence,
+			Speaker:    first.Speaker,
+		}
+	}
+	replacement[len(replacement)-1].End = last.End
+	return replacement
+}
+
+func normaliseWords(words []string) []string {
+	out := make([]string, len(words))
+	for k, w := range words {
+		out[k] = normaliseWord(w)
+	}
+	return out
+}
+
+func normaliseWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, isPunctuation))
+}
+
+func equalWords(a []string, b []string) bool {
+	return strings.Join(a, " ") == strings.Join(b, " ")
+}
+
+func leadingPunctuation(word string) string {
+	return word[:len(word)-len(strings.TrimLeftFunc(word, isPunctuation))]
+}
+
+func trailingPunctuation(word string) string {
+	return word[len(strings.TrimRightFunc(word, isPunctuation)):]
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) && r != '\''
+}
+
+// NewSet creates a set of vocabularies stored as <publication>.json in the given dir. The publication of a media
+// file is found using the file patterns, relative to the media dir if given, otherwise just the file name is used.
+// Nil is returned if no dir is given.
+func NewSet(dir string, patterns metadata.FilePatterns, mediaDir string) *Set {
+	if dir == "" {
+		return nil
+	}
+	return &Set{
+		dir:      dir,
+		patterns: patterns,
+		mediaDir: mediaDir,
+		loaded:   map[string]loadedVocabulary{},
+	}
+}
+
+// Set loads vocabularies on demand so they can be edited without a restart.
+type Set struct {
+	dir      string
+	patterns metadata.FilePatterns
+	mediaDir string
+	loaded   map[string]loadedVocabulary
+	lock     sync.Mutex
+}
+
+type loadedVocabulary struct {
+	modTime time.Time
+	vocab   *Vocabulary
+}
+
+// ForMedia returns the vocabulary for the publication of the given media file. Nil is returned if the
+// publication has no vocabulary.
+func (s *Set) ForMedia(mediaPath string) (*Vocabulary, error) {
+	if s == nil {
+		return nil, nil
+	}
+	name, err := s.patterns.Parse(s.relativePath(mediaPath))
+	if err != nil || name.Publication == "" {
+		return nil, nil
+	}
+	return s.ForPublication(name.Publication)
+}
+
+func (s *Set) ForPublication(publication string) (*Vocabulary, error) {
+	if s == nil {
+		return nil, nil
+	}
+	vocabPath := path.Join(s.dir, fmt.Sprintf("%s.json", publication))
+	info, err := os.Stat(vocabPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if loaded, ok := s.loaded[publication]; ok && loaded.modTime.Equal(info.ModTime()) {
+		return loaded.vocab, nil
+	}
+	vocab, err := LoadVocabulary(vocabPath)
+	if err != nil {
+		return nil, err
+	}
+	s.loaded[publication] = loadedVocabulary{modTime: info.ModTime(), vocab: vocab}
+	return vocab, nil
+}
+
+func (s *Set) relativePath(mediaPath string) string {
+	if s.mediaDir != "" {
+		if rel, err := filepath.Rel(s.mediaDir, mediaPath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path.Base(mediaPath)
+}
@@ -0,0 +1,93 @@
+package vocabulary
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/warmans/audio-search-bot/internal/metadata"
+	"github.com/warmans/audio-search-bot/internal/model"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVocabulary_Correct(t *testing.T) {
+	words := func(text string) *model.Transcript {
+		transcript := &model.Transcript{}
+		for k, w := range strings.Fields(text) {
+			transcript.Words = append(transcript.Words, model.TranscriptWord{
+				Text:       w,
+				Start:      time.Second * time.Duration(k),
+				End:        time.Second * time.Duration(k+1),
+				Confidence: 0.9,
+				Speaker:    "A",
+			})
+		}
+		return transcript
+	}
+	text := func(transcript *model.Transcript) string {
+		out := []string{}
+		for _, w := range transcript.Words {
+			out = append(out, w.Text)
+		}
+		return strings.Join(out, " ")
+	}
+
+	vocab := &Vocabulary{Spelling: []Spelling{
+		{From: []string{"carl", "Carol"}, To: "Karl"},
+		{From: []string{"monkey nudes"}, To: "Monkey News"},
+		{From: []string{"pilks"}, To: "Karl Pilkington"},
+	}}
+
+	transcript := words("Carl said \"carl?\" and then did monkey nudes, with pilks.")
+	require.EqualValues(t, 4, vocab.Correct(transcript))
+	require.EqualValues(t, "Karl said \"Karl?\" and then did Monkey News, with Karl Pilkington.", text(transcript))
+
+	// the replacement covers the time taken by the original words
+	last := transcript.Words[len(transcript.Words)-2:]
+	require.EqualValues(t, time.Second*9, last[0].Start)
+	require.EqualValues(t, time.Second*9+time.Millisecond*500, last[0].End)
+	require.EqualValues(t, time.Second*10, last[1].End)
+	require.EqualValues(t, "A", last[1].Speaker)
+
+	// correcting again is a noop
+	require.EqualValues(t, 0, vocab.Correct(transcript))
+
+	// replacements that contain the original words are only applied once.
+	vocab = &Vocabulary{Spelling: []Spelling{
+		{From: []string{"pilkington"}, To: "Karl Pilkington"},
+		{From: []string{"ricky"}, To: "Ricky Gervais"},
+	}}
+	transcript = words("Ricky and pilkington.")
+	require.EqualValues(t, 2, vocab.Correct(transcript))
+	require.EqualValues(t, "Ricky Gervais and Karl Pilkington.", text(transcript))
+	require.EqualValues(t, 0, vocab.Correct(transcript))
+	require.EqualValues(t, "Ricky Gervais and Karl Pilkington.", text(transcript))
+
+	// nil vocabularies are allowed
+	require.EqualValues(t, 0, (*Vocabulary)(nil).Correct(transcript))
+}
+
+func TestSet_ForMedia(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "xfm.json"), []byte(`{"word_boost": ["Pilkington"], "spelling": [{"from": ["carl"], "to": "Karl"}]}`), 0644))
+
+	set := NewSet(dir, metadata.DefaultFilePatterns(), "/media")
+
+	vocab, err := set.ForMedia("/media/xfm/xfm-S01E01.mp3")
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"Pilkington"}, vocab.WordBoost)
+
+	vocab, err = set.ForMedia("/media/radio-S01E01.mp3")
+	require.NoError(t, err)
+	require.Nil(t, vocab)
+
+	// edits are picked up
+	require.NoError(t, os.WriteFile(path.Join(dir, "xfm.json"), []byte(`{"word_boost": ["Ricky"]}`), 0644))
+	require.NoError(t, os.Chtimes(path.Join(dir, "xfm.json"), time.Now(), time.Now().Add(time.Second)))
+	vocab, err = set.ForMedia("/media/xfm/xfm-S01E01.mp3")
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"Ricky"}, vocab.WordBoost)
+
+	require.Nil(t, NewSet("", metadata.DefaultFilePatterns(), ""))
+}
@@ -0,0 +1,134 @@
+package whisper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/warmans/audio-search-bot/internal/model"
+	ffmpeg_go "github.com/warmans/ffmpeg-go"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+)
+
+// NewClient creates a client for the whisper.cpp CLI e.g. whisper-cli -m models/ggml-base.en.bin
+func NewClient(logger *slog.Logger, binaryPath string, modelPath string) *Client {
+	return &Client{
+		logger:     logger,
+		binaryPath: binaryPath,
+		modelPath:  modelPath,
+	}
+}
+
+type Client struct {
+	logger     *slog.Logger
+	binaryPath string
+	modelPath  string
+}
+
+func (c *Client) Transcribe(ctx context.Context, mediaPath string) (*model.Transcript, error) {
+	if c.modelPath == "" {
+		return nil, fmt.Errorf("no whisper model was configured")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "whisper")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// whisper.cpp only reliably reads 16kHz wav files.
+	wavPath := path.Join(tmpDir, "input.wav")
+	err = ffmpeg_go.
+		Input(mediaPath, ffmpeg_go.KwArgs{}).
+		Output(wavPath, ffmpeg_go.KwArgs{"ar": 16000, "ac": 1, "c:a": "pcm_s16le"}).
+		OverWriteOutput().
+		Silent(true).
+		Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s to wav: %w", mediaPath, err)
+	}
+
+	c.logger.Info("Running whisper...", slog.String("i", mediaPath), slog.String("model", c.modelPath))
+
+	// a max segment length of 1 with split on word gives one segment per word.
+	outputPath := path.Join(tmpDir, "output")
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, c.binaryPath, "-m", c.modelPath, "-f", wavPath, "-ojf", "-of", outputPath, "-ml", "1", "-sow", "-np")
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("whisper failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	f, err := os.Open(outputPath + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open whisper output: %w", err)
+	}
+	defer f.Close()
+
+	return parseOutput(f)
+}
+
+type output struct {
+	Transcription []segment `json:"transcription"`
+}
+
+type segment struct {
+	Offsets offsets `json:"offsets"`
+	Text    string  `json:"text"`
+	Tokens  []token `json:"tokens"`
+}
+
+type offsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+type token struct {
+	Text        string  `json:"text"`
+	Probability float64 `json:"p"`
+}
+
+// parseOutput reads the full JSON output (-ojf) of whisper.cpp where each segment is a single word.
+func parseOutput(r io.Reader) (*model.Transcript, error) {
+	out := &output{}
+	if err := json.NewDecoder(r).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode whisper output: %w", err)
+	}
+	transcript := &model.Transcript{Words: []model.TranscriptWord{}}
+	for _, seg := range out.Transcription {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		transcript.Words = append(transcript.Words, model.TranscriptWord{
+			Text:       text,
+			Start:      time.Duration(seg.Offsets.From) * time.Millisecond,
+			End:        time.Duration(seg.Offsets.To) * time.Millisecond,
+			Confidence: confidence(seg.Tokens),
+		})
+	}
+	return transcript, nil
+}
+
+// confidence is the mean probability of the word's tokens, excluding special tokens e.g. [_BEG_].
+func confidence(tokens []token) float64 {
+	var total float64
+	var num int
+	for _, v := range tokens {
+		if strings.HasPrefix(v.Text, "[_") {
+			continue
+		}
+		total += v.Probability
+		num++
+	}
+	if num == 0 {
+		return 0
+	}
+	return total / float64(num)
+}
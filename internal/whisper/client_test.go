@@ -0,0 +1,28 @@
+package whisper
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseOutput(t *testing.T) {
+	f, err := os.Open("testdata/output.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	transcript, err := parseOutput(f)
+	require.NoError(t, err)
+	require.Len(t, transcript.Words, 2)
+
+	require.EqualValues(t, "Monkey", transcript.Words[0].Text)
+	require.EqualValues(t, 0, transcript.Words[0].Start)
+	require.EqualValues(t, time.Millisecond*420, transcript.Words[0].End)
+	require.InDelta(t, 0.8, transcript.Words[0].Confidence, 0.001)
+
+	require.EqualValues(t, "news.", transcript.Words[1].Text)
+	require.EqualValues(t, time.Millisecond*420, transcript.Words[1].Start)
+	require.EqualValues(t, time.Millisecond*910, transcript.Words[1].End)
+	require.InDelta(t, 0.9, transcript.Words[1].Confidence, 0.001)
+}